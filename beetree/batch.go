@@ -0,0 +1,289 @@
+package beetree
+
+import "slices"
+
+// BatchOp is a write of a batch applied by ApplyBatch: the key is deleted if
+// Delete is true, otherwise it is added with Value.
+type BatchOp[K, V any] struct {
+	Key    K
+	Value  V
+	Delete bool
+}
+
+// InsertBatch adds the keys to the btree like Insert does for every key. When
+// a key appears more than once, the last value is kept.
+func (bt *BeeTree[K, V]) InsertBatch(keys []Key[K, V]) {
+	ops := make([]BatchOp[K, V], len(keys))
+	for i, k := range keys {
+		ops[i] = BatchOp[K, V]{Key: k.K, Value: k.V}
+	}
+	bt.applyBatchOps(ops)
+}
+
+// DeleteBatch deletes the keys from the btree like Delete does for every key.
+func (bt *BeeTree[K, V]) DeleteBatch(keys []K) {
+	ops := make([]BatchOp[K, V], len(keys))
+	for i, k := range keys {
+		ops[i] = BatchOp[K, V]{Key: k, Delete: true}
+	}
+	bt.applyBatchOps(ops)
+}
+
+// ApplyBatch applies the writes of the batch to the btree. The result is the
+// same as applying them one by one in order, so when a key appears more than
+// once only its last write counts.
+//
+// The batch is sorted and pushed down the btree in a single pass: every node is
+// visited once for all the keys that go through it, and is split or merged at
+// most once for the whole batch instead of once per key.
+func (bt *BeeTree[K, V]) ApplyBatch(ops []BatchOp[K, V]) {
+	bt.applyBatchOps(slices.Clone(ops))
+}
+
+// applyBatchOps is ApplyBatch for a batch that it can reorder.
+func (bt *BeeTree[K, V]) applyBatchOps(ops []BatchOp[K, V]) {
+	if len(ops) == 0 {
+		return
+	}
+	bt.version++
+
+	// The sort is stable, so the last write of a key is the last one of its run.
+	slices.SortStableFunc(ops, func(a, b BatchOp[K, V]) int { return bt.compare(a.Key, b.Key) })
+	last := 0
+	for i := 1; i < len(ops); i++ {
+		if bt.compare(ops[i].Key, ops[last].Key) != 0 {
+			last++
+		}
+		ops[last] = ops[i]
+	}
+	ops = ops[:last+1]
+
+	if bt.Root == nil {
+		bt.Root = bt.newNode()
+	}
+	bt.Root = bt.Root.mutableFor(bt.cow)
+	nodes, separators := bt.applyBatch(bt.Root, ops)
+	bt.Root, _ = bt.newRoot(nodes, separators)
+
+	// The root can also be left without keys, which removes levels instead. Like
+	// Delete, a btree left without keys keeps an empty leaf as root.
+	for len(bt.Root.Keys) == 0 && len(bt.Root.Children) == 1 {
+		bt.Root = bt.Root.Children[0]
+	}
+}
+
+// newRoot returns the root of a subtree with the nodes, separated by the
+// separators. It adds as many levels above the nodes as needed for a single
+// root, and returns how many.
+func (bt *BeeTree[K, V]) newRoot(nodes []*Node[K, V], separators []Key[K, V]) (*Node[K, V], int) {
+	levels := 0
+	for len(nodes) > 1 {
+		root := bt.newNode()
+		root.Keys = append(root.Keys, separators...)
+		root.Children = append(root.Children, nodes...)
+		nodes, separators = bt.splitNode(root)
+		levels++
+	}
+	return nodes[0], levels
+}
+
+// applyBatch applies the sorted writes, which have different keys, to the
+// subtree rooted at node, which must be owned by the btree.
+//
+// The subtree is replaced by the returned nodes, which have the height of node
+// and are separated by the returned keys. When there is more than one node, all
+// of them are valid. A single node can have too few keys, even none with one
+// child, and then must be merged with a sibling by the parent.
+func (bt *BeeTree[K, V]) applyBatch(node *Node[K, V], ops []BatchOp[K, V]) ([]*Node[K, V], []Key[K, V]) {
+	if len(node.Children) == 0 {
+		keys := make([]Key[K, V], 0, len(node.Keys)+len(ops))
+		i, j := 0, 0
+		for i < len(node.Keys) || j < len(ops) {
+			c := 0
+			switch {
+			case i == len(node.Keys):
+				c = 1
+			case j < len(ops):
+				c = bt.compare(node.Keys[i].K, ops[j].Key)
+			default:
+				c = -1
+			}
+
+			if c < 0 {
+				keys = append(keys, node.Keys[i])
+				i++
+				continue
+			}
+
+			if c == 0 {
+				i++
+				if ops[j].Delete {
+					bt.length--
+				}
+			} else if !ops[j].Delete {
+				bt.length++
+			}
+			if !ops[j].Delete {
+				keys = append(keys, Key[K, V]{K: ops[j].Key, V: ops[j].Value})
+			}
+			j++
+		}
+
+		node.Keys = keys
+		return bt.splitNode(node)
+	}
+
+	// The writes are split between the keys of the node and its children. The
+	// children with writes are replaced by the nodes they are split in.
+	keys := make([]Key[K, V], 0, len(node.Keys))
+	children := make([]*Node[K, V], 0, len(node.Children))
+	var deleted []int
+	j := 0
+	for i := range node.Children {
+		start := j
+		for j < len(ops) && (i == len(node.Keys) || bt.compare(ops[j].Key, node.Keys[i].K) < 0) {
+			j++
+		}
+		if j > start {
+			nodes, separators := bt.applyBatch(node.mutableChild(i, bt.cow), ops[start:j])
+			children = append(children, nodes...)
+			keys = append(keys, separators...)
+		} else {
+			children = append(children, node.Children[i])
+		}
+
+		if i == len(node.Keys) {
+			break
+		}
+		key := node.Keys[i]
+		if j < len(ops) && bt.compare(ops[j].Key, key.K) == 0 {
+			if ops[j].Delete {
+				deleted = append(deleted, len(keys))
+				bt.length--
+			} else {
+				key.V = ops[j].Value
+			}
+			j++
+		}
+		keys = append(keys, key)
+	}
+	node.Keys, node.Children = keys, children
+
+	// A deleted key is removed by joining the children around it. They are joined
+	// from the last one, so the indexes of the other deleted keys do not change.
+	for _, i := range slices.Backward(deleted) {
+		nodes, separators := bt.join(node.mutableChild(i, bt.cow), node.mutableChild(i+1, bt.cow))
+		node.Keys = slices.Replace(node.Keys, i, i+1, separators...)
+		node.Children = slices.Replace(node.Children, i, i+2, nodes...)
+	}
+
+	bt.fixChildren(node)
+	return bt.splitNode(node)
+}
+
+// join concatenates the subtrees rooted at left and right, which have the same
+// height and must be owned by the btree, without a key between them. The nodes
+// returned are like the ones of applyBatch.
+func (bt *BeeTree[K, V]) join(left, right *Node[K, V]) ([]*Node[K, V], []Key[K, V]) {
+	if len(left.Children) == 0 {
+		left.Keys = append(left.Keys, right.Keys...)
+		return bt.splitNode(left)
+	}
+
+	// The last child of left and the first child of right are joined in turn, down
+	// to the leaves.
+	last := len(left.Children) - 1
+	nodes, separators := bt.join(left.mutableChild(last, bt.cow), right.mutableChild(0, bt.cow))
+	left.Keys = append(append(left.Keys, separators...), right.Keys...)
+	left.Children = append(append(left.Children[:last], nodes...), right.Children[1:]...)
+
+	bt.fixChildren(left)
+	return bt.splitNode(left)
+}
+
+// fixChildren merges the children of the node that have too few keys with a
+// sibling. It must be owned by the btree. Only a node with a single child can be
+// left with a child with too few keys.
+func (bt *BeeTree[K, V]) fixChildren(node *Node[K, V]) {
+	for i := 0; i < len(node.Children) && len(node.Children) > 1; {
+		if len(node.Children[i].Keys) >= bt.Degree-1 {
+			i++
+			continue
+		}
+
+		// The child is merged with the next sibling, or with the previous one if it
+		// is the last child. The merged node is checked again, since the next
+		// sibling may not have been fixed yet.
+		left := i
+		if i == len(node.Children)-1 {
+			left = i - 1
+		}
+		nodes, _ := bt.mergeSiblings(node, left)
+		if len(nodes) > 1 {
+			i = left + len(nodes)
+		} else {
+			i = left
+		}
+	}
+}
+
+// mergeSiblings merges the children at index i and i+1 of the node, which must
+// be owned by the btree, with the key between them. The merged node replaces
+// both children, split again if it has too many keys.
+func (bt *BeeTree[K, V]) mergeSiblings(node *Node[K, V], i int) ([]*Node[K, V], []Key[K, V]) {
+	left, right := node.mutableChild(i, bt.cow), node.Children[i+1]
+	left.Keys = append(append(left.Keys, node.Keys[i]), right.Keys...)
+	left.Children = append(left.Children, right.Children...)
+
+	// A merged child with a single child may leave a child of its own with too
+	// few keys, which can be merged now that it has siblings.
+	bt.fixChildren(left)
+	nodes, separators := bt.splitNode(left)
+	node.Keys = slices.Replace(node.Keys, i, i+1, separators...)
+	node.Children = slices.Replace(node.Children, i, i+2, nodes...)
+	return nodes, separators
+}
+
+// splitNode splits a node with too many keys into the fewest valid nodes that
+// can hold them, and returns them with the keys that separate them. A node
+// that is not too big is returned as is. The sizes of the nodes are updated.
+func (bt *BeeTree[K, V]) splitNode(node *Node[K, V]) ([]*Node[K, V], []Key[K, V]) {
+	if len(node.Keys) <= 2*bt.Degree-1 {
+		node.updateSize()
+		return []*Node[K, V]{node}, nil
+	}
+
+	// With k keys, n nodes have k-(n-1) keys between them. Using as many nodes as
+	// needed for 2*Degree-1 keys leaves at least Degree-1 keys in every node.
+	keys, children := node.Keys, node.Children
+	n := (len(keys) + 2*bt.Degree) / (2 * bt.Degree)
+	nodeKeys := len(keys) - (n - 1)
+	nodes := make([]*Node[K, V], 0, n)
+	separators := make([]Key[K, V], 0, n-1)
+	start := 0
+	for i := 0; i < n; i++ {
+		count := nodeKeys / n
+		if i < nodeKeys%n {
+			count++
+		}
+
+		part := node
+		if i > 0 {
+			part = bt.newNode()
+		}
+		part.Keys = append(make([]Key[K, V], 0, 2*bt.Degree-1), keys[start:start+count]...)
+		if len(children) > 0 {
+			part.Children = append(make([]*Node[K, V], 0, 2*bt.Degree), children[start:start+count+1]...)
+		}
+		part.updateSize()
+		nodes = append(nodes, part)
+
+		start += count
+		if i < n-1 {
+			separators = append(separators, keys[start])
+			start++
+		}
+	}
+
+	return nodes, separators
+}
@@ -0,0 +1,125 @@
+package beetree
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// TestApplyBatchRandomOperations tests random batches of mixed writes against a map, with batches
+// of all sizes so that nodes are split and merged many times in a single batch
+func TestApplyBatchRandomOperations(t *testing.T) {
+	for degree := 2; degree <= 5; degree++ {
+		tree := NewBeetree[int, int](degree)
+		expected := map[int]int{}
+
+		for round := 0; round < 300; round++ {
+			keyRange := 1 + rand.Intn(2000)
+			deleteRatio := rand.Float64()
+			ops := make([]BatchOp[int, int], rand.Intn(1+rand.Intn(500)))
+			for i := range ops {
+				ops[i] = BatchOp[int, int]{Key: rand.Intn(keyRange), Value: rand.Int(), Delete: rand.Float64() < deleteRatio}
+			}
+
+			tree.ApplyBatch(ops)
+			for _, op := range ops {
+				if op.Delete {
+					delete(expected, op.Key)
+				} else {
+					expected[op.Key] = op.Value
+				}
+			}
+
+			if err := tree.Verify(); err != nil {
+				t.Fatalf("Degree %d, round %d: %v", degree, round, err)
+			}
+			if !reflect.DeepEqual(collectPairs(tree), expected) {
+				t.Fatalf("Degree %d, round %d: tree does not match the expected keys", degree, round)
+			}
+		}
+	}
+}
+
+// TestInsertDeleteBatch tests inserting and deleting batches of keys
+func TestInsertDeleteBatch(t *testing.T) {
+	tree := NewBeetree[int, int](3)
+	tree.InsertBatch(perm(1000))
+	tree.InsertBatch([]Key[int, int]{{K: 5, V: 1}, {K: 5, V: 2}, {K: 1000, V: 3}})
+	if tree.Len() != 1001 {
+		t.Errorf("Expected 1001 keys, got %d", tree.Len())
+	}
+	if v, _ := tree.Get(5); v != 2 {
+		t.Errorf("Expected the last value 2 of a repeated key, got %d", v)
+	}
+	verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
+
+	var odd []int
+	for i := 1; i < 1001; i += 2 {
+		odd = append(odd, i)
+	}
+	tree.DeleteBatch(append(odd, -1, 2000))
+	if tree.Len() != 501 {
+		t.Errorf("Expected 501 keys, got %d", tree.Len())
+	}
+	for i := 0; i <= 1000; i++ {
+		if tree.Has(i) != (i%2 == 0) {
+			t.Fatalf("Unexpected presence of key %d", i)
+		}
+	}
+	verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
+
+	tree.DeleteBatch(rang(1001))
+	if tree.Len() != 0 {
+		t.Errorf("Expected an empty tree, got %d keys", tree.Len())
+	}
+	if len(tree.Root.Keys) != 0 || len(tree.Root.Children) != 0 {
+		t.Errorf("Expected an empty leaf root like Delete leaves")
+	}
+	tree.InsertBatch(perm(10))
+	verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
+}
+
+// TestApplyBatchLastWriteWins tests that the writes of a batch count in order
+func TestApplyBatchLastWriteWins(t *testing.T) {
+	tree := NewBeetree[int, int](2)
+	tree.Put(1, 1)
+	tree.Put(2, 2)
+
+	tree.ApplyBatch([]BatchOp[int, int]{
+		{Key: 1, Delete: true},
+		{Key: 1, Value: 10},
+		{Key: 2, Value: 20},
+		{Key: 2, Delete: true},
+		{Key: 3, Value: 30},
+		{Key: 3, Delete: true},
+		{Key: 3, Value: 31},
+	})
+
+	if !reflect.DeepEqual(collectPairs(tree), map[int]int{1: 10, 3: 31}) {
+		t.Errorf("Unexpected keys %v", collectPairs(tree))
+	}
+}
+
+// TestApplyBatchOnClone tests that a batch applied to a clone does not change the original tree
+func TestApplyBatchOnClone(t *testing.T) {
+	tree := NewBeetree[int, int](3)
+	tree.InsertBatch(perm(1000))
+	before := collectPairs(tree)
+
+	clone := tree.Clone()
+	ops := make([]BatchOp[int, int], 0, 1000)
+	for i := 0; i < 1000; i++ {
+		ops = append(ops, BatchOp[int, int]{Key: i * 3 % 1500, Value: i, Delete: i%3 == 0})
+	}
+	clone.ApplyBatch(ops)
+
+	if !reflect.DeepEqual(collectPairs(tree), before) {
+		t.Errorf("Original tree was modified by a batch applied to its clone")
+	}
+	if err := tree.Verify(); err != nil {
+		t.Errorf("Verify failed on the original tree: %v", err)
+	}
+	if err := clone.Verify(); err != nil {
+		t.Errorf("Verify failed on the clone: %v", err)
+	}
+}
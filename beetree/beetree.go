@@ -1,7 +1,9 @@
 package beetree
 
 import (
+	"cmp"
 	"fmt"
+	"iter"
 )
 
 // CLRS B Trees
@@ -13,31 +15,102 @@ import (
 // Intermediary nodes must have min t-1 keys and t children.
 // Leaf nodes must have min t-1 keys.
 
-type Key struct {
-	K int
+// Key is an entry stored in a node. K is used to order the entries in the tree
+// and V is the value attached to it.
+type Key[K, V any] struct {
+	K K `json:"key"`
+	V V `json:"value"`
 }
 
-type Node struct {
-	Keys     []Key
-	Children []*Node
+type Node[K, V any] struct {
+	Keys     []Key[K, V]
+	Children []*Node[K, V]
+
+	// size is the number of keys stored in the subtree rooted at this node.
+	size int
+
+	// cow is the copy on write context of the btree that owns the node. Only that
+	// btree is allowed to modify the node.
+	cow *copyOnWriteContext
 }
 
-type BeeTree struct {
+type BeeTree[K, V any] struct {
 	Degree int
-	Root   *Node
+	Root   *Node[K, V]
+
+	// length is the number of keys stored in the btree.
+	length int
+
+	// compare returns a negative number when a < b, zero when a == b and a
+	// positive number when a > b.
+	compare func(a, b K) int
+
+	// version is incremented on every modification of the btree so that cursors
+	// can detect that the tree changed under them.
+	version uint64
+
+	cow *copyOnWriteContext
+}
+
+// copyOnWriteContext determines node ownership. A btree can only modify nodes
+// that have the same context as the btree, any other node is shared with a clone
+// and must be copied before being modified.
+//
+// Before a node is modified, a copy owned by the btree replaces it in its parent
+// node, so that the path from the root to any modified node is always owned by
+// the btree doing the write.
+type copyOnWriteContext struct {
+	// Contexts are compared by address and pointers to distinct zero-size values
+	// may be equal, so the context must not be empty.
+	_ byte
+}
+
+func NewNode[K, V any](degree int) *Node[K, V] {
+	return &Node[K, V]{
+		Keys:     make([]Key[K, V], 0, (2*degree)-1),
+		Children: make([]*Node[K, V], 0, 2*degree),
+	}
+}
+
+// mutableFor returns the node if it is owned by cow, otherwise it returns a copy
+// of the node owned by cow.
+func (n *Node[K, V]) mutableFor(cow *copyOnWriteContext) *Node[K, V] {
+	if n.cow == cow {
+		return n
+	}
+
+	out := &Node[K, V]{
+		Keys:     make([]Key[K, V], len(n.Keys), max(cap(n.Keys), len(n.Keys))),
+		Children: make([]*Node[K, V], len(n.Children), max(cap(n.Children), len(n.Children))),
+		size:     n.size,
+		cow:      cow,
+	}
+	copy(out.Keys, n.Keys)
+	copy(out.Children, n.Children)
+
+	return out
 }
 
-func NewNode(degree int) *Node {
-	return &Node{
-		make([]Key, 0, (2*degree)-1),
-		make([]*Node, 0, 2*degree),
+// mutableChild replaces the child at index i with a copy owned by cow if needed
+// and returns it.
+func (n *Node[K, V]) mutableChild(i int, cow *copyOnWriteContext) *Node[K, V] {
+	c := n.Children[i].mutableFor(cow)
+	n.Children[i] = c
+	return c
+}
+
+// updateSize sets the size of the node from its keys and the size of its children.
+func (n *Node[K, V]) updateSize() {
+	n.size = len(n.Keys)
+	for _, c := range n.Children {
+		n.size += c.size
 	}
 }
 
-func (n *Node) insertKeyInSortedOrder(key Key) int {
+func (n *Node[K, V]) insertKeyInSortedOrder(key Key[K, V], compare func(a, b K) int) int {
 	for i, k := range n.Keys {
-		if key.K < k.K {
-			rightKeys := make([]Key, len(n.Keys[i:]))
+		if compare(key.K, k.K) < 0 {
+			rightKeys := make([]Key[K, V], len(n.Keys[i:]))
 			copy(rightKeys, n.Keys[i:])
 
 			n.Keys = n.Keys[:i]
@@ -50,7 +123,7 @@ func (n *Node) insertKeyInSortedOrder(key Key) int {
 	return len(n.Keys) - 1
 }
 
-func (n *Node) deleteKeyByIndex(index int) {
+func (n *Node[K, V]) deleteKeyByIndex(index int) {
 	newKeys := n.Keys[:index]
 	if index < len(n.Keys)-1 {
 		newKeys = append(newKeys, n.Keys[index+1:]...)
@@ -63,13 +136,14 @@ func (n *Node) deleteKeyByIndex(index int) {
 //
 // If index of key is -1, the key was not found in the current node and index of child should be used
 // to continue traversing the tree.
-func (n *Node) findIndexOfKey(key Key) (int, int) {
+func (n *Node[K, V]) findIndexOfKey(key K, compare func(a, b K) int) (int, int) {
 	for i, k := range n.Keys {
-		if key.K == k.K {
+		c := compare(key, k.K)
+		if c == 0 {
 			return i, -1
 		}
 
-		if key.K < k.K {
+		if c < 0 {
 			return -1, i
 		}
 	}
@@ -77,42 +151,103 @@ func (n *Node) findIndexOfKey(key Key) (int, int) {
 	return -1, len(n.Keys)
 }
 
-func NewBeetree(degree int) *BeeTree {
-	return &BeeTree{
-		Degree: degree,
+// NewBeetree creates a BeeTree of the given degree for keys with a natural
+// ordering.
+func NewBeetree[K cmp.Ordered, V any](degree int) *BeeTree[K, V] {
+	return NewBeetreeFunc[K, V](degree, cmp.Compare[K])
+}
+
+// NewBeetreeFunc creates a BeeTree of the given degree whose keys are ordered
+// by compare. compare must return a negative number when a < b, zero when
+// a == b and a positive number when a > b.
+func NewBeetreeFunc[K, V any](degree int, compare func(a, b K) int) *BeeTree[K, V] {
+	return &BeeTree[K, V]{
+		Degree:  degree,
+		compare: compare,
+		cow:     &copyOnWriteContext{},
 	}
 }
 
-func (bt *BeeTree) Insert(key Key) {
+// Clone clones the btree lazily. Clone should not be called concurrently, but
+// the original btree and the clone can be used concurrently once Clone returns.
+//
+// The nodes of the btree are shared between both btrees and marked read only.
+// Writes to any of them copy the shared nodes they modify, so the original
+// btree and the clone never see each other changes.
+func (bt *BeeTree[K, V]) Clone() *BeeTree[K, V] {
+	// Both btrees get a new context so that none of them owns the shared nodes.
+	cow1, cow2 := *bt.cow, *bt.cow
+	out := *bt
+	bt.cow = &cow1
+	out.cow = &cow2
+	return &out
+}
+
+// newNode creates a node owned by the btree.
+func (bt *BeeTree[K, V]) newNode() *Node[K, V] {
+	n := NewNode[K, V](bt.Degree)
+	n.cow = bt.cow
+	return n
+}
+
+// Insert adds the key and its value to the btree. If the key already exists, its
+// value is replaced.
+func (bt *BeeTree[K, V]) Insert(key Key[K, V]) {
+	bt.Put(key.K, key.V)
+}
+
+// Put adds the key with the given value to the btree. If the key already exists,
+// its value is replaced and the previous value is returned together with true.
+func (bt *BeeTree[K, V]) Put(key K, value V) (V, bool) {
+	bt.version++
+
 	if bt.Root == nil {
-		bt.Root = NewNode(bt.Degree)
-		bt.Root.Keys = append(bt.Root.Keys, key)
-		return
+		bt.Root = bt.newNode()
+		bt.Root.Keys = append(bt.Root.Keys, Key[K, V]{K: key, V: value})
+		bt.Root.size = 1
+		bt.length++
+		var zero V
+		return zero, false
 	}
 
-	newrightChildNode, middleKey := bt.insert(bt.Root, key)
+	bt.Root = bt.Root.mutableFor(bt.cow)
+	newrightChildNode, middleKey, replaced := bt.insert(bt.Root, Key[K, V]{K: key, V: value})
+	if replaced {
+		return middleKey.V, true
+	}
+	bt.length++
+
 	// If a key has been returned to root, it means the tree has grown and a new
 	// level must be created with a new root containing the returned key.
 	if newrightChildNode != nil {
-		newRootNode := NewNode(bt.Degree)
+		newRootNode := bt.newNode()
 		newRootNode.Keys = append(newRootNode.Keys, middleKey)
 		newRootNode.Children = append(newRootNode.Children, bt.Root)
 		newRootNode.Children = append(newRootNode.Children, newrightChildNode)
+		newRootNode.updateSize()
 		bt.Root = newRootNode
 	}
+
+	var zero V
+	return zero, false
 }
 
-func (bt *BeeTree) insert(node *Node, key Key) (*Node, Key) {
+// insert adds the key to the subtree rooted at node.
+//
+// If node was split, the new right node and the middle key that must be inserted in
+// the parent node are returned. If the key already existed, its previous version is
+// returned as the second value and the third value is true.
+func (bt *BeeTree[K, V]) insert(node *Node[K, V], key Key[K, V]) (*Node[K, V], Key[K, V], bool) {
 	// This holds the index of the child node that was split and it is used
 	// to determine in what position to insert the new child node.
 	var indexOfSplitNode = -1
-	var newSplitrightChildNode *Node
+	var newSplitrightChildNode *Node[K, V]
 
 	// Check if key already exists in current node.
 	var keyExists bool
 	var indexOfDuplicatedKey int
 	for i, k := range node.Keys {
-		if key.K == k.K {
+		if bt.compare(key.K, k.K) == 0 {
 			keyExists = true
 			indexOfDuplicatedKey = i
 			break
@@ -125,7 +260,7 @@ func (bt *BeeTree) insert(node *Node, key Key) (*Node, Key) {
 		if len(node.Children) > 0 {
 			// We search first if key should be in left nodes.
 			for i, k := range node.Keys {
-				if key.K < k.K {
+				if bt.compare(key.K, k.K) < 0 {
 					indexOfSplitNode = i
 					break
 				}
@@ -137,9 +272,13 @@ func (bt *BeeTree) insert(node *Node, key Key) (*Node, Key) {
 				indexOfSplitNode = len(node.Keys)
 			}
 
-			newSplitrightChildNode, key = bt.insert(node.Children[indexOfSplitNode], key)
+			var replaced bool
+			newSplitrightChildNode, key, replaced = bt.insert(node.mutableChild(indexOfSplitNode, bt.cow), key)
 			if newSplitrightChildNode == nil {
-				return nil, Key{}
+				if !replaced {
+					node.size++
+				}
+				return nil, key, replaced
 			}
 		}
 
@@ -148,7 +287,7 @@ func (bt *BeeTree) insert(node *Node, key Key) (*Node, Key) {
 		// contains the keys bigger than the middle key. These will be returned to the parent
 		// so that the middle can be inserted and new child node appended if it also has space
 		// otherwise parent is also split.
-		var newrightChildNode *Node
+		var newrightChildNode *Node[K, V]
 		if len(node.Keys) == 2*bt.Degree-1 {
 			// Store the middle key that needs to be sent upwards
 			// to the parent node.
@@ -156,7 +295,7 @@ func (bt *BeeTree) insert(node *Node, key Key) (*Node, Key) {
 			middleKey := node.Keys[middleIndex]
 
 			// Create new child node with keys bigger than middle key and their children.
-			newrightChildNode = NewNode(bt.Degree)
+			newrightChildNode = bt.newNode()
 			newrightChildNode.Keys = append(newrightChildNode.Keys, node.Keys[middleIndex+1:]...)
 			if len(node.Children) >= middleIndex+1 {
 				newrightChildNode.Children = append(newrightChildNode.Children, node.Children[middleIndex+1:]...)
@@ -171,8 +310,8 @@ func (bt *BeeTree) insert(node *Node, key Key) (*Node, Key) {
 			// Insert new key in left or right new child node.
 			// If new key is less than the middle key it should be in the
 			// left node otherwise in the right node.
-			if key.K < middleKey.K {
-				indexOfInsertedKey := node.insertKeyInSortedOrder(key)
+			if bt.compare(key.K, middleKey.K) < 0 {
+				indexOfInsertedKey := node.insertKeyInSortedOrder(key, bt.compare)
 				if newSplitrightChildNode != nil {
 					// Insert the split child at the correct position in the left node
 					insertPos := indexOfInsertedKey + 1
@@ -185,7 +324,7 @@ func (bt *BeeTree) insert(node *Node, key Key) (*Node, Key) {
 					}
 				}
 			} else {
-				indexOfInsertedKey := newrightChildNode.insertKeyInSortedOrder(key)
+				indexOfInsertedKey := newrightChildNode.insertKeyInSortedOrder(key, bt.compare)
 				if newSplitrightChildNode != nil {
 					// Insert the split child at the correct position in the right node by
 					// using the index of the key that was inserted. The split child node should be
@@ -201,7 +340,10 @@ func (bt *BeeTree) insert(node *Node, key Key) (*Node, Key) {
 				}
 			}
 
-			return newrightChildNode, middleKey
+			node.updateSize()
+			newrightChildNode.updateSize()
+
+			return newrightChildNode, middleKey, false
 		}
 	}
 
@@ -209,9 +351,11 @@ func (bt *BeeTree) insert(node *Node, key Key) (*Node, Key) {
 	// We also check if one of its child nodes was split so that a new child node must
 	// added to the list of children.
 	if keyExists {
+		oldKey := node.Keys[indexOfDuplicatedKey]
 		node.Keys[indexOfDuplicatedKey] = key
+		return nil, oldKey, true
 	} else {
-		indexOfInsertedKey := node.insertKeyInSortedOrder(key)
+		indexOfInsertedKey := node.insertKeyInSortedOrder(key, bt.compare)
 
 		if newSplitrightChildNode != nil {
 			// Insert the new split child at the correct position
@@ -223,26 +367,38 @@ func (bt *BeeTree) insert(node *Node, key Key) (*Node, Key) {
 			copy(node.Children[insertPos+1:], node.Children[insertPos:])
 			node.Children[insertPos] = newSplitrightChildNode
 		}
+		node.updateSize()
 	}
 
-	return nil, Key{}
+	return nil, Key[K, V]{}, false
 }
 
-func (bt *BeeTree) Get(key int) Key {
+// Get returns the value stored for the key. The second value reports whether
+// the key was found.
+func (bt *BeeTree[K, V]) Get(key K) (V, bool) {
 	if bt.Root == nil {
-		return Key{}
+		var zero V
+		return zero, false
 	}
 
-	return bt.get(bt.Root, key)
+	k, found := bt.get(bt.Root, key)
+	return k.V, found
+}
+
+// Has reports whether the key is stored in the btree.
+func (bt *BeeTree[K, V]) Has(key K) bool {
+	_, found := bt.Get(key)
+	return found
 }
 
-func (bt *BeeTree) get(node *Node, key int) Key {
+func (bt *BeeTree[K, V]) get(node *Node[K, V], key K) (Key[K, V], bool) {
 	// Check if key exists in current node
 	for i, k := range node.Keys {
-		if key == k.K {
-			return k
+		c := bt.compare(key, k.K)
+		if c == 0 {
+			return k, true
 		}
-		if key < k.K {
+		if c < 0 {
 			// Key should be in left child
 			if len(node.Children) > i {
 				return bt.get(node.Children[i], key)
@@ -256,7 +412,195 @@ func (bt *BeeTree) get(node *Node, key int) Key {
 		return bt.get(node.Children[len(node.Keys)], key)
 	}
 
-	return Key{}
+	return Key[K, V]{}, false
+}
+
+// ItemIterator allows callers of Ascend* and Descend* to iterate in order over
+// portions of the tree. When this function returns false, iteration will stop and
+// the associated Ascend* or Descend* function will immediately return.
+type ItemIterator[K, V any] func(key K, value V) bool
+
+// Ascend calls the iterator for every key in the btree within the range
+// [first, last], until iterator returns false.
+func (bt *BeeTree[K, V]) Ascend(iterator ItemIterator[K, V]) {
+	if bt.Root == nil {
+		return
+	}
+	bt.ascend(bt.Root, nil, nil, iterator)
+}
+
+// AscendRange calls the iterator for every key in the btree within the range
+// [greaterOrEqual, lessThan), until iterator returns false.
+func (bt *BeeTree[K, V]) AscendRange(greaterOrEqual, lessThan K, iterator ItemIterator[K, V]) {
+	if bt.Root == nil {
+		return
+	}
+	bt.ascend(bt.Root, &greaterOrEqual, &lessThan, iterator)
+}
+
+// AscendGreaterOrEqual calls the iterator for every key in the btree within the
+// range [pivot, last], until iterator returns false.
+func (bt *BeeTree[K, V]) AscendGreaterOrEqual(pivot K, iterator ItemIterator[K, V]) {
+	if bt.Root == nil {
+		return
+	}
+	bt.ascend(bt.Root, &pivot, nil, iterator)
+}
+
+// AscendLessThan calls the iterator for every key in the btree within the range
+// [first, pivot), until iterator returns false.
+func (bt *BeeTree[K, V]) AscendLessThan(pivot K, iterator ItemIterator[K, V]) {
+	if bt.Root == nil {
+		return
+	}
+	bt.ascend(bt.Root, nil, &pivot, iterator)
+}
+
+// Descend calls the iterator for every key in the btree within the range
+// [last, first], until iterator returns false.
+func (bt *BeeTree[K, V]) Descend(iterator ItemIterator[K, V]) {
+	if bt.Root == nil {
+		return
+	}
+	bt.descend(bt.Root, nil, nil, iterator)
+}
+
+// DescendRange calls the iterator for every key in the btree within the range
+// [lessOrEqual, greaterThan), until iterator returns false.
+func (bt *BeeTree[K, V]) DescendRange(lessOrEqual, greaterThan K, iterator ItemIterator[K, V]) {
+	if bt.Root == nil {
+		return
+	}
+	bt.descend(bt.Root, &lessOrEqual, &greaterThan, iterator)
+}
+
+// DescendLessOrEqual calls the iterator for every key in the btree within the
+// range [pivot, first], until iterator returns false.
+func (bt *BeeTree[K, V]) DescendLessOrEqual(pivot K, iterator ItemIterator[K, V]) {
+	if bt.Root == nil {
+		return
+	}
+	bt.descend(bt.Root, &pivot, nil, iterator)
+}
+
+// DescendGreaterThan calls the iterator for every key in the btree within the
+// range [last, pivot), until iterator returns false.
+func (bt *BeeTree[K, V]) DescendGreaterThan(pivot K, iterator ItemIterator[K, V]) {
+	if bt.Root == nil {
+		return
+	}
+	bt.descend(bt.Root, nil, &pivot, iterator)
+}
+
+// All returns an iterator over all the keys and values of the btree in
+// ascending order.
+func (bt *BeeTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		bt.Ascend(yield)
+	}
+}
+
+// Backward returns an iterator over all the keys and values of the btree in
+// descending order.
+func (bt *BeeTree[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		bt.Descend(yield)
+	}
+}
+
+// Range returns an iterator over the keys and values of the btree within the
+// range [greaterOrEqual, lessThan) in ascending order.
+func (bt *BeeTree[K, V]) Range(greaterOrEqual, lessThan K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		bt.AscendRange(greaterOrEqual, lessThan, yield)
+	}
+}
+
+// ascend walks the subtree rooted at node in ascending order calling the iterator
+// for every key that is greater or equal than greaterOrEqual and less than lessThan.
+// A nil bound means the range is not limited on that side.
+//
+// It returns false when the iteration must stop, either because the iterator
+// returned false or because a key beyond lessThan was reached.
+func (bt *BeeTree[K, V]) ascend(node *Node[K, V], greaterOrEqual, lessThan *K, iterator ItemIterator[K, V]) bool {
+	// Keys and children on the left of the first key that is greater or equal than
+	// the lower bound can be skipped.
+	start := 0
+	if greaterOrEqual != nil {
+		indexOfKey, indexOfChild := node.findIndexOfKey(*greaterOrEqual, bt.compare)
+		start = indexOfChild
+		if indexOfKey >= 0 {
+			start = indexOfKey
+		}
+	}
+
+	for i := start; i < len(node.Keys); i++ {
+		if len(node.Children) > 0 {
+			if !bt.ascend(node.Children[i], greaterOrEqual, lessThan, iterator) {
+				return false
+			}
+		}
+
+		if lessThan != nil && bt.compare(node.Keys[i].K, *lessThan) >= 0 {
+			return false
+		}
+
+		if !iterator(node.Keys[i].K, node.Keys[i].V) {
+			return false
+		}
+	}
+
+	// Most right child.
+	if len(node.Children) > 0 {
+		return bt.ascend(node.Children[len(node.Keys)], greaterOrEqual, lessThan, iterator)
+	}
+
+	return true
+}
+
+// descend walks the subtree rooted at node in descending order calling the iterator
+// for every key that is less or equal than lessOrEqual and greater than greaterThan.
+// A nil bound means the range is not limited on that side.
+//
+// It returns false when the iteration must stop, either because the iterator
+// returned false or because a key beyond greaterThan was reached.
+func (bt *BeeTree[K, V]) descend(node *Node[K, V], lessOrEqual, greaterThan *K, iterator ItemIterator[K, V]) bool {
+	// Keys and children on the right of the last key that is less or equal than
+	// the upper bound can be skipped.
+	start := len(node.Keys) - 1
+	if lessOrEqual != nil {
+		indexOfKey, indexOfChild := node.findIndexOfKey(*lessOrEqual, bt.compare)
+		start = indexOfChild - 1
+		if indexOfKey >= 0 {
+			start = indexOfKey
+		}
+	}
+
+	// The child on the right of the first key holds keys between that key and the
+	// upper bound.
+	if len(node.Children) > 0 {
+		if !bt.descend(node.Children[start+1], lessOrEqual, greaterThan, iterator) {
+			return false
+		}
+	}
+
+	for i := start; i >= 0; i-- {
+		if greaterThan != nil && bt.compare(node.Keys[i].K, *greaterThan) <= 0 {
+			return false
+		}
+
+		if !iterator(node.Keys[i].K, node.Keys[i].V) {
+			return false
+		}
+
+		if len(node.Children) > 0 {
+			if !bt.descend(node.Children[i], lessOrEqual, greaterThan, iterator) {
+				return false
+			}
+		}
+	}
+
+	return true
 }
 
 // PrintInLevelOrder prints the keys in the BeeTree in level order.
@@ -265,7 +609,10 @@ func (bt *BeeTree) get(node *Node, key int) Key {
 // separated by colons.
 //
 // Example: 0:0:{20} -> 0[parent index]:0[node index]:{20}key
-func (bt *BeeTree) PrintInLevelOrder() {
+//
+// The output is meant to be read by people, use Layout to get the nodes in a
+// format that can be encoded and loaded back.
+func (bt *BeeTree[K, V]) PrintInLevelOrder() {
 	// Empty btree.
 	if bt.Root == nil {
 		return
@@ -273,13 +620,13 @@ func (bt *BeeTree) PrintInLevelOrder() {
 
 	// We create a slice with the nodes at each level, we start with root so
 	// it is a slice of one node.
-	nodes := make([]map[int]*Node, 0)
-	nodes = append(nodes, map[int]*Node{-1: bt.Root})
+	nodes := make([]map[int]*Node[K, V], 0)
+	nodes = append(nodes, map[int]*Node[K, V]{-1: bt.Root})
 	bt.printInLevelOrder(nodes)
 }
 
-func (bt *BeeTree) printInLevelOrder(nodes []map[int]*Node) {
-	childrenNodes := make([]map[int]*Node, 0)
+func (bt *BeeTree[K, V]) printInLevelOrder(nodes []map[int]*Node[K, V]) {
+	childrenNodes := make([]map[int]*Node[K, V], 0)
 
 	// For every node in this level we print their keys and then create
 	// a slice with the children nodes.
@@ -290,7 +637,7 @@ func (bt *BeeTree) printInLevelOrder(nodes []map[int]*Node) {
 			}
 
 			for _, c := range node.Children {
-				childrenWithParentIndex := map[int]*Node{i: c}
+				childrenWithParentIndex := map[int]*Node[K, V]{i: c}
 				childrenNodes = append(childrenNodes, childrenWithParentIndex)
 			}
 		}
@@ -302,36 +649,53 @@ func (bt *BeeTree) printInLevelOrder(nodes []map[int]*Node) {
 	}
 }
 
-// Delete deletes a key from the btree if found.
-func (bt *BeeTree) Delete(key Key) {
-	// If btree is empty, we return.
+// Delete deletes a key from the btree if found and returns its value. The second
+// value reports whether the key was found.
+func (bt *BeeTree[K, V]) Delete(key K) (V, bool) {
+	// If btree is empty or does not have the key, we return.
 	if bt.Root == nil {
-		return
+		var zero V
+		return zero, false
 	}
 
-	bt.delete(bt.Root, key)
+	root, deletedKey, found := bt.delete(bt.Root, key)
+	if !found {
+		return deletedKey.V, false
+	}
+	bt.Root = root
+	bt.version++
+	bt.length--
 
 	// Check if current root must be replaced by its child
 	// If root has no keys but has one child, the child becomes the root.
 	if len(bt.Root.Keys) == 0 && len(bt.Root.Children) == 1 {
 		bt.Root = bt.Root.Children[0]
 	}
+
+	return deletedKey.V, found
 }
 
-func (bt *BeeTree) delete(node *Node, key Key) {
+// delete deletes the key from the subtree rooted at node and returns the root of
+// the subtree afterwards, owned by the btree, and the deleted key. The last value
+// reports whether the key was found; if not, node is returned unchanged, so that
+// the path to a missing key is never copied.
+func (bt *BeeTree[K, V]) delete(node *Node[K, V], key K) (*Node[K, V], Key[K, V], bool) {
 	// Find if the key is in the current node or in which child node it could be.
-	indexOfKey, indexOfChild := node.findIndexOfKey(key)
+	indexOfKey, indexOfChild := node.findIndexOfKey(key, bt.compare)
 
 	// If the key is found in this node, we proceed with the deletion of the key
 	// and return.
 	// The parent node should check if node is underflow due to the deletion of one of its keys.
 	if indexOfKey >= 0 {
+		node = node.mutableFor(bt.cow)
+		deletedKey := node.Keys[indexOfKey]
+
 		// If node does not have children, it is a leaf node
 		// otherwise it is an internal node.
 		if len(node.Children) == 0 {
 			// For leaf nodes, we just delete the key and we let the
 			// recursive function to handle underflow nodes.
-			newKeys := make([]Key, 0, 2*bt.Degree-1)
+			newKeys := make([]Key[K, V], 0, 2*bt.Degree-1)
 			for i, k := range node.Keys {
 				if i != indexOfKey {
 					newKeys = append(newKeys, k)
@@ -339,7 +703,8 @@ func (bt *BeeTree) delete(node *Node, key Key) {
 			}
 
 			node.Keys = newKeys
-			return
+			node.size--
+			return node, deletedKey, true
 		} else {
 			// For internal nodes, we need to replace the key to be deleted with a key
 			// from one of its predessesor or successor child nodes.
@@ -352,11 +717,13 @@ func (bt *BeeTree) delete(node *Node, key Key) {
 			// underflow.
 			preNode := bt.findPredecessor(node.Children[indexOfKey])
 			if len(preNode.Keys) > bt.Degree-1 {
+				preNode = bt.shrinkPath(node, indexOfKey, false)
 				node.deleteKeyByIndex(indexOfKey)
-				node.insertKeyInSortedOrder(preNode.Keys[len(preNode.Keys)-1])
+				node.insertKeyInSortedOrder(preNode.Keys[len(preNode.Keys)-1], bt.compare)
 				preNode.deleteKeyByIndex(len(preNode.Keys) - 1)
+				node.size--
 
-				return
+				return node, deletedKey, true
 			}
 
 			// Check if sucessor key can be used without creating
@@ -364,11 +731,13 @@ func (bt *BeeTree) delete(node *Node, key Key) {
 			sucNode := bt.findSuccessor(node.Children[indexOfKey+1])
 			if len(sucNode.Keys) > bt.Degree-1 {
 				// Replace deleted key with sucessor key.
+				sucNode = bt.shrinkPath(node, indexOfKey+1, true)
 				node.deleteKeyByIndex(indexOfKey)
-				node.insertKeyInSortedOrder(sucNode.Keys[0])
+				node.insertKeyInSortedOrder(sucNode.Keys[0], bt.compare)
 				sucNode.deleteKeyByIndex(0)
+				node.size--
 
-				return
+				return node, deletedKey, true
 			}
 
 			// If underflow can not be avoided, replace leaf key for the deleted key in the
@@ -381,7 +750,14 @@ func (bt *BeeTree) delete(node *Node, key Key) {
 			// Then, we initiate the deletion from the predecessor child, so that we can get to the leaf node
 			// and delete the key used for replacement.
 			// We can not start from the current node, since it already has the key that we want to delete from the leaf node.
-			bt.delete(node.Children[indexOfKey], preKey)
+			node.Children[indexOfKey], _, _ = bt.delete(node.Children[indexOfKey], preKey.K)
+
+			// The predecessor child only needs to be fixed if it is underflow, merging it
+			// otherwise could leave it with more keys than allowed.
+			if len(node.Children[indexOfKey].Keys) >= bt.Degree-1 {
+				node.updateSize()
+				return node, deletedKey, true
+			}
 
 			// Redistribution.
 			// We find a left or right sibling node with enough keys so that we borrow one of their
@@ -393,24 +769,32 @@ func (bt *BeeTree) delete(node *Node, key Key) {
 				// and pull the separating key from the parent.
 				bt.merge(node, indexOfKey)
 			}
+			node.updateSize()
 
-			return
+			return node, deletedKey, true
 		}
 	}
 
 	// If key is not in current node, we validate if the node has children otherwise this means that the key is not
 	// in the tree.
 	if len(node.Children) == 0 {
-		return
+		return node, Key[K, V]{}, false
 	}
 
 	// We move to the child where the key could be located. This index of child was returned from the find function.
-	bt.delete(node.Children[indexOfChild], key)
+	// The node is only copied once the key was found below it.
+	child, deletedKey, found := bt.delete(node.Children[indexOfChild], key)
+	if !found {
+		return node, deletedKey, false
+	}
+	node = node.mutableFor(bt.cow)
+	node.Children[indexOfChild] = child
 
 	// Once returns, we check if child node is underflow due to the deletion of a key.
 	// If not we return to finish the operation, otherwise if it is underflow, we redistribute or merge.
-	if len(node.Children[indexOfChild].Keys) >= bt.Degree-1 {
-		return
+	if len(child.Keys) >= bt.Degree-1 {
+		node.size--
+		return node, deletedKey, true
 	}
 
 	// Redistribution.
@@ -423,10 +807,237 @@ func (bt *BeeTree) delete(node *Node, key Key) {
 		// and pull the separating key from the parent.
 		bt.merge(node, indexOfChild)
 	}
+	node.updateSize()
+
+	return node, deletedKey, true
+}
+
+// Len returns the number of keys stored in the btree.
+func (bt *BeeTree[K, V]) Len() int {
+	return bt.length
+}
+
+// Min returns the smallest key of the btree and its value. The last value
+// reports whether the btree has keys.
+func (bt *BeeTree[K, V]) Min() (K, V, bool) {
+	if bt.length == 0 {
+		var zeroK K
+		var zeroV V
+		return zeroK, zeroV, false
+	}
+
+	// The smallest key is the first key of the most left leaf node.
+	node := bt.findSuccessor(bt.Root)
+	return node.Keys[0].K, node.Keys[0].V, true
+}
+
+// Max returns the largest key of the btree and its value. The last value
+// reports whether the btree has keys.
+func (bt *BeeTree[K, V]) Max() (K, V, bool) {
+	if bt.length == 0 {
+		var zeroK K
+		var zeroV V
+		return zeroK, zeroV, false
+	}
+
+	// The largest key is the last key of the most right leaf node.
+	node := bt.findPredecessor(bt.Root)
+	return node.Keys[len(node.Keys)-1].K, node.Keys[len(node.Keys)-1].V, true
+}
+
+// DeleteMin deletes the smallest key of the btree and returns it with its value.
+// The last value reports whether the btree had keys.
+func (bt *BeeTree[K, V]) DeleteMin() (K, V, bool) {
+	key, _, found := bt.Min()
+	if !found {
+		var zeroV V
+		return key, zeroV, false
+	}
+
+	value, _ := bt.Delete(key)
+	return key, value, true
+}
+
+// DeleteMax deletes the largest key of the btree and returns it with its value.
+// The last value reports whether the btree had keys.
+func (bt *BeeTree[K, V]) DeleteMax() (K, V, bool) {
+	key, _, found := bt.Max()
+	if !found {
+		var zeroV V
+		return key, zeroV, false
+	}
+
+	value, _ := bt.Delete(key)
+	return key, value, true
+}
+
+// Floor returns the largest key of the btree that is less or equal than key and
+// its value. The last value reports whether such a key exists.
+func (bt *BeeTree[K, V]) Floor(key K) (K, V, bool) {
+	k, found := bt.neighbor(key, true, false)
+	return k.K, k.V, found
+}
+
+// Ceiling returns the smallest key of the btree that is greater or equal than
+// key and its value. The last value reports whether such a key exists.
+func (bt *BeeTree[K, V]) Ceiling(key K) (K, V, bool) {
+	k, found := bt.neighbor(key, true, true)
+	return k.K, k.V, found
+}
+
+// Lower returns the largest key of the btree that is less than key and its
+// value. The last value reports whether such a key exists.
+func (bt *BeeTree[K, V]) Lower(key K) (K, V, bool) {
+	k, found := bt.neighbor(key, false, false)
+	return k.K, k.V, found
+}
+
+// Higher returns the smallest key of the btree that is greater than key and its
+// value. The last value reports whether such a key exists.
+func (bt *BeeTree[K, V]) Higher(key K) (K, V, bool) {
+	k, found := bt.neighbor(key, false, true)
+	return k.K, k.V, found
+}
+
+// neighbor finds the closest key to key in a single descent from the root to a
+// leaf node. If inclusive is true, key itself is returned when found. If above is
+// true, the closest greater key is searched, otherwise the closest smaller key.
+func (bt *BeeTree[K, V]) neighbor(key K, inclusive, above bool) (Key[K, V], bool) {
+	var candidate Key[K, V]
+	var found bool
+
+	node := bt.Root
+	for node != nil {
+		indexOfKey, indexOfChild := node.findIndexOfKey(key, bt.compare)
+		if indexOfKey >= 0 {
+			if inclusive {
+				return node.Keys[indexOfKey], true
+			}
+
+			// The neighbors of the key are its adjacent keys or they are in the
+			// children on each side of the key.
+			indexOfChild = indexOfKey
+			if above {
+				indexOfChild = indexOfKey + 1
+			}
+		}
+
+		// Keys of the node closer to key than the current candidate. Any key in
+		// the child node between them is even closer.
+		if above && indexOfChild < len(node.Keys) {
+			candidate, found = node.Keys[indexOfChild], true
+		}
+		if !above && indexOfChild > 0 {
+			candidate, found = node.Keys[indexOfChild-1], true
+		}
+
+		if len(node.Children) == 0 {
+			break
+		}
+		node = node.Children[indexOfChild]
+	}
+
+	return candidate, found
+}
+
+// shrinkPath prepares the path from the child at indexOfChild to its most left
+// leaf node if leftmost is true, or to its most right leaf node otherwise, for
+// a key to be taken from that leaf node without traversing the path.
+//
+// Every node in the path is made mutable and its size decremented. The leaf node
+// is returned.
+func (bt *BeeTree[K, V]) shrinkPath(node *Node[K, V], indexOfChild int, leftmost bool) *Node[K, V] {
+	node = node.mutableChild(indexOfChild, bt.cow)
+	for {
+		node.size--
+		if len(node.Children) == 0 {
+			return node
+		}
+
+		if leftmost {
+			node = node.mutableChild(0, bt.cow)
+		} else {
+			node = node.mutableChild(len(node.Children)-1, bt.cow)
+		}
+	}
+}
+
+// Rank returns the number of keys of the btree that are less than key.
+func (bt *BeeTree[K, V]) Rank(key K) int {
+	rank := 0
+
+	node := bt.Root
+	for node != nil {
+		indexOfKey, indexOfChild := node.findIndexOfKey(key, bt.compare)
+
+		// Keys on the left of the index and their children are less than key.
+		index := indexOfChild
+		if indexOfKey >= 0 {
+			index = indexOfKey
+		}
+		rank += index
+		if len(node.Children) == 0 {
+			break
+		}
+		for _, c := range node.Children[:index] {
+			rank += c.size
+		}
+
+		// The child on the left of the key only holds keys less than key.
+		if indexOfKey >= 0 {
+			rank += node.Children[indexOfKey].size
+			break
+		}
+		node = node.Children[indexOfChild]
+	}
+
+	return rank
+}
+
+// Select returns the key with the given rank, that is the key that has exactly
+// rank keys less than it, and its value. The last value reports whether rank is
+// within [0, Len()).
+func (bt *BeeTree[K, V]) Select(rank int) (K, V, bool) {
+	if rank < 0 || rank >= bt.length {
+		var zeroK K
+		var zeroV V
+		return zeroK, zeroV, false
+	}
+
+	node := bt.Root
+	for len(node.Children) > 0 {
+		// Skip the children and keys on the left until the subtree holding the rank
+		// is found.
+		i := 0
+		for ; i < len(node.Keys); i++ {
+			if rank < node.Children[i].size {
+				break
+			}
+			rank -= node.Children[i].size
+
+			if rank == 0 {
+				return node.Keys[i].K, node.Keys[i].V, true
+			}
+			rank--
+		}
+		node = node.Children[i]
+	}
+
+	return node.Keys[rank].K, node.Keys[rank].V, true
+}
+
+// CountRange returns the number of keys of the btree within the range
+// [greaterOrEqual, lessThan).
+func (bt *BeeTree[K, V]) CountRange(greaterOrEqual, lessThan K) int {
+	if bt.compare(greaterOrEqual, lessThan) >= 0 {
+		return 0
+	}
+
+	return bt.Rank(lessThan) - bt.Rank(greaterOrEqual)
 }
 
 // findPredecessor finds the largest key on the left child of a node.
-func (bt *BeeTree) findPredecessor(node *Node) *Node {
+func (bt *BeeTree[K, V]) findPredecessor(node *Node[K, V]) *Node[K, V] {
 	// Check if this is a leaf node and return.
 	if len(node.Children) == 0 {
 		return node
@@ -437,7 +1048,7 @@ func (bt *BeeTree) findPredecessor(node *Node) *Node {
 }
 
 // findSuccessor finds the smallest key on the right child of a node.
-func (bt *BeeTree) findSuccessor(node *Node) *Node {
+func (bt *BeeTree[K, V]) findSuccessor(node *Node[K, V]) *Node[K, V] {
 	// Check if this is a leaf node and return.
 	if len(node.Children) == 0 {
 		return node
@@ -447,7 +1058,7 @@ func (bt *BeeTree) findSuccessor(node *Node) *Node {
 	return bt.findSuccessor(node.Children[0])
 }
 
-func (bt *BeeTree) redistribute(node *Node, indexOfChild int) bool {
+func (bt *BeeTree[K, V]) redistribute(node *Node[K, V], indexOfChild int) bool {
 	// We borrow from left sibling.
 	// If this is not the first child.
 	// If left sibling has enought keys.
@@ -455,19 +1066,22 @@ func (bt *BeeTree) redistribute(node *Node, indexOfChild int) bool {
 		// We get the key from the parent that will go to the underflow node.
 		parentKey := node.Keys[indexOfChild-1]
 
-		underflowNode := node.Children[indexOfChild]
-		underflowNode.insertKeyInSortedOrder(parentKey)
+		underflowNode := node.mutableChild(indexOfChild, bt.cow)
+		underflowNode.insertKeyInSortedOrder(parentKey, bt.compare)
 
-		leftSiblingNode := node.Children[indexOfChild-1]
+		leftSiblingNode := node.mutableChild(indexOfChild-1, bt.cow)
 		node.Keys[indexOfChild-1] = leftSiblingNode.Keys[len(leftSiblingNode.Keys)-1]
 
 		// Only move children if the nodes have children (not leaf nodes)
 		if len(leftSiblingNode.Children) > 0 {
-			underflowNode.Children = append([]*Node{leftSiblingNode.Children[len(leftSiblingNode.Children)-1]}, underflowNode.Children...)
-			leftSiblingNode.Children = append(make([]*Node, 0, 2*bt.Degree), leftSiblingNode.Children[:len(leftSiblingNode.Children)-1]...)
+			underflowNode.Children = append([]*Node[K, V]{leftSiblingNode.Children[len(leftSiblingNode.Children)-1]}, underflowNode.Children...)
+			leftSiblingNode.Children = append(make([]*Node[K, V], 0, 2*bt.Degree), leftSiblingNode.Children[:len(leftSiblingNode.Children)-1]...)
 		}
 
-		leftSiblingNode.Keys = append(make([]Key, 0, 2*bt.Degree-1), leftSiblingNode.Keys[:len(leftSiblingNode.Keys)-1]...)
+		leftSiblingNode.Keys = append(make([]Key[K, V], 0, 2*bt.Degree-1), leftSiblingNode.Keys[:len(leftSiblingNode.Keys)-1]...)
+
+		underflowNode.updateSize()
+		leftSiblingNode.updateSize()
 
 		return true
 	}
@@ -478,19 +1092,22 @@ func (bt *BeeTree) redistribute(node *Node, indexOfChild int) bool {
 	if indexOfChild < len(node.Keys) && len(node.Children[indexOfChild+1].Keys) > bt.Degree-1 {
 		parentKey := node.Keys[indexOfChild]
 
-		underflowNode := node.Children[indexOfChild]
-		underflowNode.insertKeyInSortedOrder(parentKey)
+		underflowNode := node.mutableChild(indexOfChild, bt.cow)
+		underflowNode.insertKeyInSortedOrder(parentKey, bt.compare)
 
-		rightSiblingNode := node.Children[indexOfChild+1]
+		rightSiblingNode := node.mutableChild(indexOfChild+1, bt.cow)
 		node.Keys[indexOfChild] = rightSiblingNode.Keys[0]
 
 		// Only move children if the nodes have children (not leaf nodes)
 		if len(rightSiblingNode.Children) > 0 {
 			underflowNode.Children = append(underflowNode.Children, rightSiblingNode.Children[0])
-			rightSiblingNode.Children = append(make([]*Node, 0, 2*bt.Degree), rightSiblingNode.Children[1:]...)
+			rightSiblingNode.Children = append(make([]*Node[K, V], 0, 2*bt.Degree), rightSiblingNode.Children[1:]...)
 		}
 
-		rightSiblingNode.Keys = append(make([]Key, 0, 2*bt.Degree-1), rightSiblingNode.Keys[1:]...)
+		rightSiblingNode.Keys = append(make([]Key[K, V], 0, 2*bt.Degree-1), rightSiblingNode.Keys[1:]...)
+
+		underflowNode.updateSize()
+		rightSiblingNode.updateSize()
 
 		return true
 	}
@@ -498,7 +1115,7 @@ func (bt *BeeTree) redistribute(node *Node, indexOfChild int) bool {
 	return false
 }
 
-func (bt *BeeTree) merge(node *Node, indexOfChild int) {
+func (bt *BeeTree[K, V]) merge(node *Node[K, V], indexOfChild int) {
 	indexOfKeyToPull := indexOfChild
 	indexOfChild1 := indexOfChild
 	indexOfChild2 := indexOfChild + 1
@@ -513,26 +1130,27 @@ func (bt *BeeTree) merge(node *Node, indexOfChild int) {
 
 	// Create the new child node with child, sibling and parent key.
 	// Insert parent key.
-	mergedNode := NewNode(bt.Degree)
-	mergedNode.insertKeyInSortedOrder(node.Keys[indexOfKeyToPull])
+	mergedNode := bt.newNode()
+	mergedNode.insertKeyInSortedOrder(node.Keys[indexOfKeyToPull], bt.compare)
 
 	for _, k := range node.Children[indexOfChild1].Keys {
-		mergedNode.insertKeyInSortedOrder(k)
+		mergedNode.insertKeyInSortedOrder(k, bt.compare)
 	}
 	mergedNode.Children = append(mergedNode.Children, node.Children[indexOfChild1].Children...)
 
 	for _, k := range node.Children[indexOfChild2].Keys {
-		mergedNode.insertKeyInSortedOrder(k)
+		mergedNode.insertKeyInSortedOrder(k, bt.compare)
 	}
 	mergedNode.Children = append(mergedNode.Children, node.Children[indexOfChild2].Children...)
+	mergedNode.updateSize()
 
 	// Remove key from parent.
-	newKeys := append(make([]Key, 0, bt.Degree-1), node.Keys[:indexOfKeyToPull]...)
+	newKeys := append(make([]Key[K, V], 0, bt.Degree-1), node.Keys[:indexOfKeyToPull]...)
 	newKeys = append(newKeys, node.Keys[indexOfKeyToPull+1:]...)
 	node.Keys = newKeys
 
 	// Update child nodes.
-	newChildren := make([]*Node, 0, 2*bt.Degree)
+	newChildren := make([]*Node[K, V], 0, 2*bt.Degree)
 	for i, n := range node.Children {
 		if i == indexOfChild1 {
 			newChildren = append(newChildren, mergedNode)
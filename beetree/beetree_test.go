@@ -3,6 +3,10 @@ package beetree
 import (
 	"fmt"
 	"math/rand"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
 	"testing"
 )
 
@@ -10,15 +14,40 @@ const benchmarkTreeSize = 10000
 const btreeDegree = 32
 
 // perm returns a random permutation of n Int items in the range [0, n).
-func perm(n int) (out []Key) {
+func perm(n int) (out []Key[int, int]) {
 	for _, v := range rand.Perm(n) {
-		out = append(out, Key{K: v})
+		out = append(out, Key[int, int]{K: v})
 	}
 	return
 }
 
+// rang returns an ordered list of ints in the range [0, n).
+func rang(n int) (out []int) {
+	for i := 0; i < n; i++ {
+		out = append(out, i)
+	}
+	return
+}
+
+// rangrev returns a reversed ordered list of ints in the range [0, n).
+func rangrev(n int) (out []int) {
+	for i := n - 1; i >= 0; i-- {
+		out = append(out, i)
+	}
+	return
+}
+
+// Helper function to build a tree with the keys of a random permutation in the range [0, n).
+func buildTreeWithPerm(degree int, n int) *BeeTree[int, int] {
+	tree := NewBeetree[int, int](degree)
+	for _, key := range perm(n) {
+		tree.Insert(key)
+	}
+	return tree
+}
+
 // Helper function to collect all keys from the tree in order
-func collectKeysInOrder(node *Node) []int {
+func collectKeysInOrder(node *Node[int, int]) []int {
 	if node == nil {
 		return []int{}
 	}
@@ -45,7 +74,7 @@ func collectKeysInOrder(node *Node) []int {
 }
 
 // Helper function to verify B-tree properties
-func verifyBTreeProperties(t *testing.T, tree *BeeTree, node *Node, minDegree int, isRoot bool) {
+func verifyBTreeProperties(t *testing.T, tree *BeeTree[int, int], node *Node[int, int], minDegree int, isRoot bool) {
 	if node == nil {
 		return
 	}
@@ -74,6 +103,15 @@ func verifyBTreeProperties(t *testing.T, tree *BeeTree, node *Node, minDegree in
 		}
 	}
 
+	// Check subtree size
+	size := len(node.Keys)
+	for _, child := range node.Children {
+		size += child.size
+	}
+	if node.size != size {
+		t.Errorf("Node has size %d, expected %d", node.size, size)
+	}
+
 	// Check children count
 	if len(node.Children) > 0 {
 		expectedChildren := len(node.Keys) + 1
@@ -90,7 +128,7 @@ func verifyBTreeProperties(t *testing.T, tree *BeeTree, node *Node, minDegree in
 
 // TestInsertEmptyTree tests inserting into an empty tree
 func TestInsertEmptyTree(t *testing.T) {
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 
 	// Verify tree is initially empty
 	if tree.Root != nil {
@@ -98,7 +136,7 @@ func TestInsertEmptyTree(t *testing.T) {
 	}
 
 	// Insert first key
-	tree.Insert(Key{K: 10})
+	tree.Insert(Key[int, int]{K: 10})
 
 	// Verify root was created
 	if tree.Root == nil {
@@ -122,12 +160,12 @@ func TestInsertEmptyTree(t *testing.T) {
 
 // TestInsertSingleNode tests inserting multiple keys without node splitting
 func TestInsertSingleNode(t *testing.T) {
-	tree := NewBeetree(3) // Max keys per node = 2*3-1 = 5
+	tree := NewBeetree[int, int](3) // Max keys per node = 2*3-1 = 5
 
 	// Insert keys in non-sorted order
 	keys := []int{30, 10, 50, 20, 40}
 	for _, k := range keys {
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 	}
 
 	// Verify all keys are in root (no splitting should occur)
@@ -153,12 +191,12 @@ func TestInsertSingleNode(t *testing.T) {
 
 // TestInsertCausingRootSplit tests inserting keys that cause root to split
 func TestInsertCausingRootSplit(t *testing.T) {
-	tree := NewBeetree(3) // Max keys per node = 5
+	tree := NewBeetree[int, int](3) // Max keys per node = 5
 
 	// Insert 6 keys to force root split
 	keys := []int{10, 20, 30, 40, 50, 60}
 	for _, k := range keys {
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 	}
 
 	// Root should have been split, creating new root with one key
@@ -193,11 +231,11 @@ func TestInsertCausingRootSplit(t *testing.T) {
 
 // TestInsertMultipleSplits tests inserting many keys causing multiple splits
 func TestInsertMultipleSplits(t *testing.T) {
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 
 	// Insert keys 1 through 20
 	for i := 1; i <= 20; i++ {
-		tree.Insert(Key{K: i})
+		tree.Insert(Key[int, int]{K: i})
 
 		// Verify B-tree properties after each insertion
 		verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
@@ -218,7 +256,7 @@ func TestInsertMultipleSplits(t *testing.T) {
 
 // TestInsertRandomOrder tests inserting keys in random order
 func TestInsertRandomOrder(t *testing.T) {
-	tree := NewBeetree(4)
+	tree := NewBeetree[int, int](4)
 
 	// Generate random permutation of keys
 	keys := perm(50)
@@ -245,11 +283,11 @@ func TestInsertRandomOrder(t *testing.T) {
 
 // TestInsertSequentialAscending tests inserting keys in ascending order
 func TestInsertSequentialAscending(t *testing.T) {
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 
 	// Insert keys 1, 2, 3, ..., 15
 	for i := 1; i <= 15; i++ {
-		tree.Insert(Key{K: i})
+		tree.Insert(Key[int, int]{K: i})
 		verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
 	}
 
@@ -268,11 +306,11 @@ func TestInsertSequentialAscending(t *testing.T) {
 
 // TestInsertSequentialDescending tests inserting keys in descending order
 func TestInsertSequentialDescending(t *testing.T) {
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 
 	// Insert keys 15, 14, 13, ..., 1
 	for i := 15; i >= 1; i-- {
-		tree.Insert(Key{K: i})
+		tree.Insert(Key[int, int]{K: i})
 		verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
 	}
 
@@ -296,7 +334,7 @@ func TestInsertDifferentDegrees(t *testing.T) {
 
 	for _, degree := range degrees {
 		t.Run(fmt.Sprintf("Degree_%d", degree), func(t *testing.T) {
-			tree := NewBeetree(degree)
+			tree := NewBeetree[int, int](degree)
 
 			// Insert keys in random order
 			keys := perm(numKeys)
@@ -323,41 +361,41 @@ func TestInsertDifferentDegrees(t *testing.T) {
 
 // TestInsertAndGet tests that inserted keys can be retrieved
 func TestInsertAndGet(t *testing.T) {
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 
 	keys := []int{10, 5, 15, 3, 7, 12, 18, 1, 4, 6, 8, 11, 13, 16, 20}
 
 	// Insert all keys
 	for _, k := range keys {
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k, V: k * 10})
 	}
 
 	// Verify all keys can be retrieved
 	for _, k := range keys {
-		result := tree.Get(k)
-		if result.K != k {
-			t.Errorf("Expected to get key %d, got %d", k, result.K)
+		value, found := tree.Get(k)
+		if !found || value != k*10 {
+			t.Errorf("Expected to get value %d for key %d, got %d (found %t)", k*10, k, value, found)
 		}
 	}
 
-	// Verify non-existent keys return empty Key
+	// Verify non-existent keys are not found
 	nonExistentKeys := []int{2, 9, 14, 17, 19, 25}
 	for _, k := range nonExistentKeys {
-		result := tree.Get(k)
-		if result.K != 0 { // Empty Key has K = 0
-			t.Errorf("Expected empty key for non-existent key %d, got %d", k, result.K)
+		value, found := tree.Get(k)
+		if found {
+			t.Errorf("Expected key %d to not be found, got value %d", k, value)
 		}
 	}
 }
 
 // TestInsertMinimumDegree tests inserting with minimum degree (2)
 func TestInsertMinimumDegree(t *testing.T) {
-	tree := NewBeetree(2) // Min degree = 2, max keys = 3
+	tree := NewBeetree[int, int](2) // Min degree = 2, max keys = 3
 
 	// Insert enough keys to cause multiple splits
 	keys := []int{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}
 	for _, k := range keys {
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 		verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
 	}
 
@@ -376,12 +414,12 @@ func TestInsertMinimumDegree(t *testing.T) {
 
 // TestInsertLargeValues tests inserting large integer values
 func TestInsertLargeValues(t *testing.T) {
-	tree := NewBeetree(4)
+	tree := NewBeetree[int, int](4)
 
 	// Insert large values
 	keys := []int{1000000, 2000000, 500000, 1500000, 750000, 1250000, 1750000}
 	for _, k := range keys {
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 		verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
 	}
 
@@ -396,12 +434,12 @@ func TestInsertLargeValues(t *testing.T) {
 
 // TestInsertNegativeValues tests inserting negative values
 func TestInsertNegativeValues(t *testing.T) {
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 
 	// Insert mix of positive and negative values
 	keys := []int{-10, 5, -20, 15, 0, -5, 25, -15}
 	for _, k := range keys {
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 		verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
 	}
 
@@ -428,7 +466,7 @@ func TestInsertNegativeValues(t *testing.T) {
 
 // TestInsertStressTest tests inserting a large number of keys
 func TestInsertStressTest(t *testing.T) {
-	tree := NewBeetree(5)
+	tree := NewBeetree[int, int](5)
 	numKeys := 1000
 
 	// Insert keys in random order
@@ -459,12 +497,12 @@ func TestInsertStressTest(t *testing.T) {
 
 // TestInsertDuplicateKeys tests that duplicate keys are not supported
 func TestInsertDuplicateKeys(t *testing.T) {
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 	
 	// Insert initial keys
-	tree.Insert(Key{K: 10})
-	tree.Insert(Key{K: 20})
-	tree.Insert(Key{K: 30})
+	tree.Insert(Key[int, int]{K: 10})
+	tree.Insert(Key[int, int]{K: 20})
+	tree.Insert(Key[int, int]{K: 30})
 	
 	// Verify initial state
 	initialKeys := collectKeysInOrder(tree.Root)
@@ -473,9 +511,9 @@ func TestInsertDuplicateKeys(t *testing.T) {
 	}
 	
 	// Attempt to insert duplicate keys
-	tree.Insert(Key{K: 10}) // Duplicate
-	tree.Insert(Key{K: 20}) // Duplicate
-	tree.Insert(Key{K: 30}) // Duplicate
+	tree.Insert(Key[int, int]{K: 10}) // Duplicate
+	tree.Insert(Key[int, int]{K: 20}) // Duplicate
+	tree.Insert(Key[int, int]{K: 30}) // Duplicate
 	
 	// Verify that duplicates were not added (keys should remain unique)
 	afterDuplicatesKeys := collectKeysInOrder(tree.Root)
@@ -503,12 +541,12 @@ func TestInsertDuplicateKeys(t *testing.T) {
 
 // TestInsertDuplicatesInLargerTree tests duplicate handling with node splits
 func TestInsertDuplicatesInLargerTree(t *testing.T) {
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 	
 	// Insert enough keys to cause splits
 	originalKeys := []int{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}
 	for _, k := range originalKeys {
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 	}
 	
 	// Count original keys and verify they're unique
@@ -524,7 +562,7 @@ func TestInsertDuplicatesInLargerTree(t *testing.T) {
 	// Insert duplicates of some keys
 	duplicateKeys := []int{20, 50, 80, 30, 90}
 	for _, k := range duplicateKeys {
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 	}
 	
 	// Verify no duplicates exist in final tree
@@ -558,32 +596,30 @@ func TestInsertDuplicatesInLargerTree(t *testing.T) {
 
 // TestInsertDuplicatesWithGet tests that duplicate insertion doesn't affect retrieval
 func TestInsertDuplicatesWithGet(t *testing.T) {
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 	
 	// Insert keys
 	keys := []int{15, 25, 35, 45, 55}
 	for _, k := range keys {
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 	}
 	
 	// Verify all keys can be found
 	for _, k := range keys {
-		result := tree.Get(k)
-		if result.K != k {
-			t.Errorf("Expected to find key %d, got %d", k, result.K)
+		if _, found := tree.Get(k); !found {
+			t.Errorf("Expected to find key %d", k)
 		}
 	}
 	
 	// Insert duplicates
 	for _, k := range keys {
-		tree.Insert(Key{K: k}) // Insert each key again
+		tree.Insert(Key[int, int]{K: k}) // Insert each key again
 	}
 	
 	// Verify keys can still be found (no corruption)
 	for _, k := range keys {
-		result := tree.Get(k)
-		if result.K != k {
-			t.Errorf("After duplicates, expected to find key %d, got %d", k, result.K)
+		if _, found := tree.Get(k); !found {
+			t.Errorf("After duplicates, expected to find key %d", k)
 		}
 	}
 	
@@ -607,12 +643,12 @@ func TestInsertDuplicatesWithGet(t *testing.T) {
 
 // TestInsertDuplicateInSingleNode tests duplicate handling within a single node
 func TestInsertDuplicateInSingleNode(t *testing.T) {
-	tree := NewBeetree(4) // Larger degree to keep everything in one node initially
+	tree := NewBeetree[int, int](4) // Larger degree to keep everything in one node initially
 	
 	// Insert keys that will fit in a single node
-	tree.Insert(Key{K: 100})
-	tree.Insert(Key{K: 200})
-	tree.Insert(Key{K: 300})
+	tree.Insert(Key[int, int]{K: 100})
+	tree.Insert(Key[int, int]{K: 200})
+	tree.Insert(Key[int, int]{K: 300})
 	
 	// Verify single node
 	if tree.Root == nil {
@@ -626,7 +662,7 @@ func TestInsertDuplicateInSingleNode(t *testing.T) {
 	}
 	
 	// Insert duplicate in the middle
-	tree.Insert(Key{K: 200})
+	tree.Insert(Key[int, int]{K: 200})
 	
 	// Verify still single node with same number of keys
 	if len(tree.Root.Keys) != 3 {
@@ -646,7 +682,7 @@ func TestInsertDuplicateInSingleNode(t *testing.T) {
 // TestDebugAdjustedIndex creates a scenario where adjustedIndex calculation is triggered
 func TestDebugAdjustedIndex(t *testing.T) {
 	// Use degree 3: max keys = 5, max children = 6, middleIndex = 2
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 	
 	// Step 1: Insert keys to create a specific tree structure
 	// Insert keys that will cause multiple splits and create the right scenario
@@ -658,7 +694,7 @@ func TestDebugAdjustedIndex(t *testing.T) {
 	
 	for i, k := range keys {
 		fmt.Printf("Inserting key %d (step %d)\n", k, i+1)
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 		fmt.Printf("Tree after inserting %d:\n", k)
 		tree.PrintInLevelOrder()
 		fmt.Println("---")
@@ -750,7 +786,7 @@ func TestTriggerAdjustedIndexWithSpecificKeys(t *testing.T) {
 	fmt.Println("\n=== Specific Keys to Trigger adjustedIndex Calculation ===")
 	fmt.Println()
 	
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 	
 	fmt.Println("Follow these exact steps to see the adjustedIndex calculation:")
 	fmt.Println()
@@ -760,7 +796,7 @@ func TestTriggerAdjustedIndexWithSpecificKeys(t *testing.T) {
 	fmt.Println("Phase 1: Create initial tree with splits")
 	for i, k := range phase1Keys {
 		fmt.Printf("Insert %d", k)
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 		if i == 5 {
 			fmt.Println(" <- This causes first major split")
 			tree.PrintInLevelOrder()
@@ -775,7 +811,7 @@ func TestTriggerAdjustedIndexWithSpecificKeys(t *testing.T) {
 	fmt.Println("Phase 2: Build deeper tree structure")
 	for _, k := range phase2Keys {
 		fmt.Printf("Insert %d\n", k)
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 	}
 	fmt.Println("Tree after Phase 2:")
 	tree.PrintInLevelOrder()
@@ -788,7 +824,7 @@ func TestTriggerAdjustedIndexWithSpecificKeys(t *testing.T) {
 	
 	for _, k := range phase3Keys {
 		fmt.Printf("Insert %d <- This should trigger the adjustedIndex path\n", k)
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 		tree.PrintInLevelOrder()
 		fmt.Println()
 	}
@@ -825,22 +861,21 @@ func TestTriggerAdjustedIndexWithSpecificKeys(t *testing.T) {
 }
 
 // Helper function to build a tree with specific keys for testing
-func buildTreeWithKeys(degree int, keys []int) *BeeTree {
-	tree := NewBeetree(degree)
+func buildTreeWithKeys(degree int, keys []int) *BeeTree[int, int] {
+	tree := NewBeetree[int, int](degree)
 	for _, k := range keys {
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 	}
 	return tree
 }
 
 // Helper function to check if a key exists in the tree
-func treeContainsKey(tree *BeeTree, key int) bool {
-	result := tree.Get(key)
-	return result.K == key
+func treeContainsKey(tree *BeeTree[int, int], key int) bool {
+	return tree.Has(key)
 }
 
 // Helper function to get tree height
-func getTreeHeight(node *Node) int {
+func getTreeHeight(node *Node[int, int]) int {
 	if node == nil || len(node.Children) == 0 {
 		return 1
 	}
@@ -848,7 +883,7 @@ func getTreeHeight(node *Node) int {
 }
 
 // Helper function to count total nodes in tree
-func countNodes(node *Node) int {
+func countNodes(node *Node[int, int]) int {
 	if node == nil {
 		return 0
 	}
@@ -861,10 +896,10 @@ func countNodes(node *Node) int {
 
 // TestDeleteEmptyTree tests deleting from an empty tree
 func TestDeleteEmptyTree(t *testing.T) {
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 	
 	// Delete from empty tree should not panic
-	tree.Delete(Key{K: 10})
+	tree.Delete(10)
 	
 	// Tree should remain empty
 	if tree.Root != nil {
@@ -878,7 +913,7 @@ func TestDeleteNonExistentKey(t *testing.T) {
 	originalKeys := collectKeysInOrder(tree.Root)
 	
 	// Delete non-existent key
-	tree.Delete(Key{K: 100})
+	tree.Delete(100)
 	
 	// Tree should remain unchanged
 	newKeys := collectKeysInOrder(tree.Root)
@@ -900,7 +935,7 @@ func TestDeleteFromSingleNodeLeaf(t *testing.T) {
 	tree := buildTreeWithKeys(3, []int{10, 20, 30})
 	
 	// Delete middle key
-	tree.Delete(Key{K: 20})
+	tree.Delete(20)
 	
 	// Verify key was deleted
 	if treeContainsKey(tree, 20) {
@@ -928,7 +963,7 @@ func TestDeleteAllKeysFromSingleNode(t *testing.T) {
 	tree := buildTreeWithKeys(3, []int{10})
 	
 	// Delete the only key
-	tree.Delete(Key{K: 10})
+	tree.Delete(10)
 	
 	// Tree should still have root but with no keys
 	if tree.Root == nil {
@@ -954,7 +989,7 @@ func TestDeleteLeafNodeNoUnderflow(t *testing.T) {
 	originalKeys := collectKeysInOrder(tree.Root)
 	
 	// Delete a key that should be in a leaf
-	tree.Delete(Key{K: 90})
+	tree.Delete(90)
 	
 	// Verify key was deleted
 	if treeContainsKey(tree, 90) {
@@ -979,7 +1014,7 @@ func TestDeleteInternalNodeWithPredecessor(t *testing.T) {
 	
 	// Delete a key that should be in an internal node
 	// This should trigger predecessor replacement
-	tree.Delete(Key{K: 50})
+	tree.Delete(50)
 	
 	// Verify key was deleted
 	if treeContainsKey(tree, 50) {
@@ -1010,7 +1045,7 @@ func TestDeleteInternalNodeWithSuccessor(t *testing.T) {
 	originalKeys := collectKeysInOrder(tree.Root)
 	
 	// Delete a key from internal node
-	tree.Delete(Key{K: 20})
+	tree.Delete(20)
 	
 	// Verify key was deleted
 	if treeContainsKey(tree, 20) {
@@ -1029,15 +1064,15 @@ func TestDeleteInternalNodeWithSuccessor(t *testing.T) {
 // TestDeleteCausingRedistributionFromLeft tests deletion causing redistribution from left sibling
 func TestDeleteCausingRedistributionFromLeft(t *testing.T) {
 	// Create a specific tree structure for testing left redistribution
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 	keys := []int{10, 20, 30, 40, 50, 60, 70}
 	for _, k := range keys {
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 	}
 	
 	// Delete a key to cause underflow that can be fixed by left redistribution
-	tree.Delete(Key{K: 70})
-	tree.Delete(Key{K: 60}) // This should cause redistribution
+	tree.Delete(70)
+	tree.Delete(60) // This should cause redistribution
 	
 	// Verify tree properties are maintained
 	verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
@@ -1059,15 +1094,15 @@ func TestDeleteCausingRedistributionFromLeft(t *testing.T) {
 
 // TestDeleteCausingRedistributionFromRight tests deletion causing redistribution from right sibling
 func TestDeleteCausingRedistributionFromRight(t *testing.T) {
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 	keys := []int{10, 20, 30, 40, 50, 60, 70}
 	for _, k := range keys {
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 	}
 	
 	// Delete keys to cause underflow that requires right redistribution
-	tree.Delete(Key{K: 10})
-	tree.Delete(Key{K: 20}) // This should cause redistribution from right
+	tree.Delete(10)
+	tree.Delete(20) // This should cause redistribution from right
 	
 	verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
 	
@@ -1087,20 +1122,20 @@ func TestDeleteCausingRedistributionFromRight(t *testing.T) {
 
 // TestDeleteCausingMerge tests deletion that causes node merging
 func TestDeleteCausingMerge(t *testing.T) {
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 	
 	// Create a tree structure that will require merging
 	keys := []int{10, 20, 30, 40, 50, 60}
 	for _, k := range keys {
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 	}
 	
 	originalNodeCount := countNodes(tree.Root)
 	
 	// Delete keys to force merge
-	tree.Delete(Key{K: 60})
-	tree.Delete(Key{K: 50})
-	tree.Delete(Key{K: 40})
+	tree.Delete(60)
+	tree.Delete(50)
+	tree.Delete(40)
 	
 	// Verify merge occurred (fewer nodes)
 	newNodeCount := countNodes(tree.Root)
@@ -1127,20 +1162,20 @@ func TestDeleteCausingMerge(t *testing.T) {
 
 // TestDeleteCausingRootChange tests deletion that changes the root
 func TestDeleteCausingRootChange(t *testing.T) {
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 	
 	// Build tree and then delete enough to potentially change root
 	keys := []int{10, 20, 30, 40, 50}
 	for _, k := range keys {
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 	}
 	
 	originalHeight := getTreeHeight(tree.Root)
 	
 	// Delete most keys
-	tree.Delete(Key{K: 10})
-	tree.Delete(Key{K: 20})
-	tree.Delete(Key{K: 30})
+	tree.Delete(10)
+	tree.Delete(20)
+	tree.Delete(30)
 	
 	newHeight := getTreeHeight(tree.Root)
 	
@@ -1162,7 +1197,7 @@ func TestDeleteSequential(t *testing.T) {
 	
 	// Delete keys 1 through 10
 	for i := 1; i <= 10; i++ {
-		tree.Delete(Key{K: i})
+		tree.Delete(i)
 		
 		// Verify key was deleted
 		if treeContainsKey(tree, i) {
@@ -1194,7 +1229,7 @@ func TestDeleteReverseSequential(t *testing.T) {
 	
 	// Delete keys 15 down to 6
 	for i := 15; i >= 6; i-- {
-		tree.Delete(Key{K: i})
+		tree.Delete(i)
 		
 		// Verify key was deleted
 		if treeContainsKey(tree, i) {
@@ -1223,13 +1258,13 @@ func TestDeleteReverseSequential(t *testing.T) {
 // TestDeleteRandomOrder tests deleting keys in random order
 func TestDeleteRandomOrder(t *testing.T) {
 	const numKeys = 50
-	tree := NewBeetree(4)
+	tree := NewBeetree[int, int](4)
 	
 	// Insert keys 1 through numKeys
 	allKeys := make([]int, numKeys)
 	for i := 0; i < numKeys; i++ {
 		allKeys[i] = i + 1
-		tree.Insert(Key{K: i + 1})
+		tree.Insert(Key[int, int]{K: i + 1})
 	}
 	
 	// Create random permutation for deletion
@@ -1241,7 +1276,7 @@ func TestDeleteRandomOrder(t *testing.T) {
 	
 	for i := 0; i < keysToDelete; i++ {
 		keyToDelete := deleteOrder[i] + 1
-		tree.Delete(Key{K: keyToDelete})
+		tree.Delete(keyToDelete)
 		deletedKeys[keyToDelete] = true
 		
 		// Verify key was deleted
@@ -1283,16 +1318,16 @@ func TestDeleteDifferentDegrees(t *testing.T) {
 	
 	for _, degree := range degrees {
 		t.Run(fmt.Sprintf("Degree_%d", degree), func(t *testing.T) {
-			tree := NewBeetree(degree)
+			tree := NewBeetree[int, int](degree)
 			
 			// Insert keys
 			for i := 1; i <= numKeys; i++ {
-				tree.Insert(Key{K: i})
+				tree.Insert(Key[int, int]{K: i})
 			}
 			
 			// Delete every other key
 			for i := 2; i <= numKeys; i += 2 {
-				tree.Delete(Key{K: i})
+				tree.Delete(i)
 				verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
 			}
 			
@@ -1321,7 +1356,7 @@ func TestDeleteAllKeys(t *testing.T) {
 	
 	// Delete all keys
 	for _, key := range originalKeys {
-		tree.Delete(Key{K: key})
+		tree.Delete(key)
 		
 		// Verify key was deleted
 		if treeContainsKey(tree, key) {
@@ -1342,12 +1377,12 @@ func TestDeleteAllKeys(t *testing.T) {
 
 // TestDeleteComplexScenario tests a complex scenario with multiple operations
 func TestDeleteComplexScenario(t *testing.T) {
-	tree := NewBeetree(3)
+	tree := NewBeetree[int, int](3)
 	
 	// Insert a larger set of keys
 	insertKeys := []int{5, 15, 25, 35, 45, 55, 65, 75, 85, 95, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100}
 	for _, k := range insertKeys {
-		tree.Insert(Key{K: k})
+		tree.Insert(Key[int, int]{K: k})
 	}
 	
 	// Delete keys in a specific pattern to test various scenarios
@@ -1363,7 +1398,7 @@ func TestDeleteComplexScenario(t *testing.T) {
 	
 	for _, keyToDelete := range deletePattern {
 		if treeContainsKey(tree, keyToDelete) {
-			tree.Delete(Key{K: keyToDelete})
+			tree.Delete(keyToDelete)
 			
 			// Verify deletion
 			if treeContainsKey(tree, keyToDelete) {
@@ -1387,13 +1422,46 @@ func TestDeleteComplexScenario(t *testing.T) {
 	}
 }
 
+// TestDeleteInternalKeyWithPredecessorChildNotUnderflowing tests deleting an internal key whose
+// predecessor and successor leaves have the minimum number of keys, when the predecessor child
+// still has enough keys after the replacement key is deleted from its subtree
+func TestDeleteInternalKeyWithPredecessorChildNotUnderflowing(t *testing.T) {
+	node := func(keys []int, children ...*Node[int, int]) *Node[int, int] {
+		n := NewNode[int, int](2)
+		for _, k := range keys {
+			n.Keys = append(n.Keys, Key[int, int]{K: k})
+		}
+		n.Children = append(n.Children, children...)
+		n.updateSize()
+		return n
+	}
+
+	// Deleting 7 from the left child redistributes its leaves and leaves it with 2 keys.
+	// Merging it with its sibling would then give a node of 4 keys.
+	tree := NewBeetree[int, int](2)
+	tree.Root = node([]int{10},
+		node([]int{3, 6}, node([]int{1}), node([]int{4, 5}), node([]int{7})),
+		node([]int{13}, node([]int{11}), node([]int{14})),
+	)
+	tree.length = tree.Root.size
+	verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
+
+	if _, found := tree.Delete(10); !found {
+		t.Fatalf("Key 10 should have been found")
+	}
+	verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
+	if keys := collectKeysInOrder(tree.Root); !reflect.DeepEqual(keys, []int{1, 3, 4, 5, 6, 7, 11, 13, 14}) {
+		t.Errorf("Unexpected keys %v", keys)
+	}
+}
+
 func BenchmarkInsert(b *testing.B) {
 	b.StopTimer()
 	insertP := perm(benchmarkTreeSize)
 	b.StartTimer()
 	i := 0
 	for i < b.N {
-		tr := NewBeetree(btreeDegree)
+		tr := NewBeetree[int, int](btreeDegree)
 		for _, item := range insertP {
 			tr.Insert(item)
 			i++
@@ -1408,7 +1476,7 @@ func BenchmarkDelete(b *testing.B) {
 	b.StopTimer()
 	
 	// Pre-build tree for deletion benchmark
-	tree := NewBeetree(btreeDegree)
+	tree := NewBeetree[int, int](btreeDegree)
 	keys := perm(benchmarkTreeSize)
 	for _, item := range keys {
 		tree.Insert(item)
@@ -1419,7 +1487,825 @@ func BenchmarkDelete(b *testing.B) {
 	
 	i := 0
 	for i < b.N && i < len(deleteOrder) {
-		tree.Delete(deleteOrder[i])
+		tree.Delete(deleteOrder[i].K)
 		i++
 	}
 }
+
+// BenchmarkInsertBatch compares inserting a batch of keys in a tree with InsertBatch and with a loop
+// of single inserts. Every iteration writes to a clone of the same tree.
+func BenchmarkInsertBatch(b *testing.B) {
+	tree := NewBeetree[int, int](btreeDegree)
+	for _, item := range perm(benchmarkTreeSize) {
+		tree.Put(item.K*2, item.K)
+	}
+
+	for _, size := range []int{100, 1000, 10000} {
+		// The batch keys fall between the keys of the tree.
+		batch := perm(size)
+		for i := range batch {
+			batch[i].K = batch[i].K*2*benchmarkTreeSize/size + 1
+		}
+
+		b.Run(fmt.Sprintf("Batch/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tree.Clone().InsertBatch(batch)
+			}
+		})
+		b.Run(fmt.Sprintf("Loop/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tr := tree.Clone()
+				for _, item := range batch {
+					tr.Insert(item)
+				}
+			}
+		})
+	}
+}
+
+// TestStringKeys tests a tree ordered by the natural order of its string keys
+func TestStringKeys(t *testing.T) {
+	tree := NewBeetree[string, int](2)
+
+	words := []string{"pear", "apple", "fig", "kiwi", "banana", "cherry", "grape", "lemon"}
+	for i, w := range words {
+		tree.Insert(Key[string, int]{K: w, V: i})
+	}
+
+	for i, w := range words {
+		value, found := tree.Get(w)
+		if !found || value != i {
+			t.Errorf("Expected value %d for %s, got %d (found %t)", i, w, value, found)
+		}
+	}
+
+	tree.Delete("fig")
+	if _, found := tree.Get("fig"); found {
+		t.Errorf("Expected fig to be deleted")
+	}
+}
+
+// TestComparatorKeys tests a tree ordered by a caller supplied comparator
+func TestComparatorKeys(t *testing.T) {
+	type point struct{ x, y int }
+
+	// Order points by x and then by y, in descending order.
+	tree := NewBeetreeFunc[point, string](2, func(a, b point) int {
+		if a.x != b.x {
+			return b.x - a.x
+		}
+		return b.y - a.y
+	})
+
+	for i := 0; i < 30; i++ {
+		p := point{i % 5, i}
+		tree.Insert(Key[point, string]{K: p, V: fmt.Sprint(i)})
+	}
+
+	var previous *point
+	var walk func(node *Node[point, string])
+	walk = func(node *Node[point, string]) {
+		for i, key := range node.Keys {
+			if len(node.Children) > 0 {
+				walk(node.Children[i])
+			}
+			if previous != nil && (previous.x < key.K.x || previous.x == key.K.x && previous.y < key.K.y) {
+				t.Errorf("Keys not in descending order: %v before %v", *previous, key.K)
+			}
+			k := key.K
+			previous = &k
+		}
+		if len(node.Children) > 0 {
+			walk(node.Children[len(node.Children)-1])
+		}
+	}
+	walk(tree.Root)
+
+	if value, _ := tree.Get(point{3, 13}); value != "13" {
+		t.Errorf("Expected value 13, got %q", value)
+	}
+}
+
+// TestPutReturnsPreviousValue tests that replacing a key returns its previous value
+func TestPutReturnsPreviousValue(t *testing.T) {
+	tree := NewBeetree[int, string](2)
+
+	// Insert enough keys to have internal nodes so replacements happen at every level.
+	for i := 0; i < 50; i++ {
+		if old, replaced := tree.Put(i, fmt.Sprint("a", i)); replaced {
+			t.Errorf("Put of new key %d returned replaced value %q", i, old)
+		}
+	}
+
+	for i := 0; i < 50; i++ {
+		old, replaced := tree.Put(i, fmt.Sprint("b", i))
+		if !replaced || old != fmt.Sprint("a", i) {
+			t.Errorf("Expected Put to replace a%d for key %d, got %q (replaced %t)", i, i, old, replaced)
+		}
+	}
+
+	for i := 0; i < 50; i++ {
+		value, found := tree.Get(i)
+		if !found || value != fmt.Sprint("b", i) {
+			t.Errorf("Expected value b%d for key %d, got %q (found %t)", i, i, value, found)
+		}
+	}
+}
+
+// TestDeleteReturnsValue tests that Delete returns the value of the deleted key
+func TestDeleteReturnsValue(t *testing.T) {
+	tree := NewBeetree[int, string](2)
+	for i := 0; i < 50; i++ {
+		tree.Put(i, fmt.Sprint("v", i))
+	}
+
+	if value, found := tree.Delete(100); found {
+		t.Errorf("Expected key 100 to not be found, got %q", value)
+	}
+
+	for _, i := range rand.Perm(50) {
+		value, found := tree.Delete(i)
+		if !found || value != fmt.Sprint("v", i) {
+			t.Errorf("Expected to delete v%d for key %d, got %q (found %t)", i, i, value, found)
+		}
+
+		if _, found := tree.Delete(i); found {
+			t.Errorf("Expected key %d to be already deleted", i)
+		}
+	}
+}
+
+// TestGetZeroAndNegativeKeys tests that a stored zero key is distinguished from a missing key
+func TestGetZeroAndNegativeKeys(t *testing.T) {
+	tree := NewBeetree[int, int](2)
+
+	// Empty tree.
+	if _, found := tree.Get(0); found {
+		t.Errorf("Expected key 0 to not be found in empty tree")
+	}
+	if tree.Has(0) {
+		t.Errorf("Expected Has(0) to be false in empty tree")
+	}
+
+	keys := []int{-30, -20, -10, -1, 1, 10, 20, 30}
+	for _, k := range keys {
+		tree.Insert(Key[int, int]{K: k, V: k})
+	}
+
+	// Key 0 has not been inserted yet.
+	if value, found := tree.Get(0); found {
+		t.Errorf("Expected key 0 to not be found, got %d", value)
+	}
+
+	tree.Insert(Key[int, int]{K: 0, V: 0})
+	if value, found := tree.Get(0); !found || value != 0 {
+		t.Errorf("Expected key 0 to be found with value 0, got %d (found %t)", value, found)
+	}
+
+	for _, k := range keys {
+		if !tree.Has(k) {
+			t.Errorf("Expected Has(%d) to be true", k)
+		}
+	}
+
+	for _, k := range []int{-31, -15, -2, 2, 15, 31} {
+		if tree.Has(k) {
+			t.Errorf("Expected Has(%d) to be false", k)
+		}
+	}
+
+	// Root without keys after deleting everything.
+	tree.Delete(0)
+	for _, k := range keys {
+		tree.Delete(k)
+	}
+	if tree.Has(0) || tree.Has(-10) {
+		t.Errorf("Expected keys to not be found after deleting all keys")
+	}
+}
+
+// TestAscend tests iterating over all keys in ascending order
+func TestAscend(t *testing.T) {
+	tree := buildTreeWithPerm(2, 100)
+
+	var got []int
+	tree.Ascend(func(k, v int) bool {
+		got = append(got, k)
+		return true
+	})
+	if want := rang(100); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ascend:\n got: %v\nwant: %v", got, want)
+	}
+
+	got = got[:0]
+	tree.Ascend(func(k, v int) bool {
+		if k > 50 {
+			return false
+		}
+		got = append(got, k)
+		return true
+	})
+	if want := rang(100)[:51]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ascend:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+// TestDescend tests iterating over all keys in descending order
+func TestDescend(t *testing.T) {
+	tree := buildTreeWithPerm(2, 100)
+
+	var got []int
+	tree.Descend(func(k, v int) bool {
+		got = append(got, k)
+		return true
+	})
+	if want := rangrev(100); !reflect.DeepEqual(got, want) {
+		t.Fatalf("descend:\n got: %v\nwant: %v", got, want)
+	}
+
+	got = got[:0]
+	tree.Descend(func(k, v int) bool {
+		if k < 50 {
+			return false
+		}
+		got = append(got, k)
+		return true
+	})
+	if want := rangrev(100)[:50]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("descend:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+// TestAscendRange tests iterating over the keys in [40, 60)
+func TestAscendRange(t *testing.T) {
+	tree := buildTreeWithPerm(2, 100)
+
+	var got []int
+	tree.AscendRange(40, 60, func(k, v int) bool {
+		got = append(got, k)
+		return true
+	})
+	if want := rang(100)[40:60]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ascendrange:\n got: %v\nwant: %v", got, want)
+	}
+
+	got = got[:0]
+	tree.AscendRange(40, 60, func(k, v int) bool {
+		if k > 50 {
+			return false
+		}
+		got = append(got, k)
+		return true
+	})
+	if want := rang(100)[40:51]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ascendrange:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+// TestDescendRange tests iterating over the keys in [60, 40)
+func TestDescendRange(t *testing.T) {
+	tree := buildTreeWithPerm(2, 100)
+
+	var got []int
+	tree.DescendRange(60, 40, func(k, v int) bool {
+		got = append(got, k)
+		return true
+	})
+	if want := rangrev(100)[39:59]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("descendrange:\n got: %v\nwant: %v", got, want)
+	}
+
+	got = got[:0]
+	tree.DescendRange(60, 40, func(k, v int) bool {
+		if k < 50 {
+			return false
+		}
+		got = append(got, k)
+		return true
+	})
+	if want := rangrev(100)[39:50]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("descendrange:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+// TestAscendLessThan tests iterating over the keys in [first, 60)
+func TestAscendLessThan(t *testing.T) {
+	tree := buildTreeWithPerm(btreeDegree, 100)
+
+	var got []int
+	tree.AscendLessThan(60, func(k, v int) bool {
+		got = append(got, k)
+		return true
+	})
+	if want := rang(100)[:60]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ascendlessthan:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+// TestAscendGreaterOrEqual tests iterating over the keys in [40, last]
+func TestAscendGreaterOrEqual(t *testing.T) {
+	tree := buildTreeWithPerm(3, 100)
+
+	var got []int
+	tree.AscendGreaterOrEqual(40, func(k, v int) bool {
+		got = append(got, k)
+		return true
+	})
+	if want := rang(100)[40:]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ascendgreaterorequal:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+// TestDescendLessOrEqual tests iterating over the keys in [40, first]
+func TestDescendLessOrEqual(t *testing.T) {
+	tree := buildTreeWithPerm(3, 100)
+
+	var got []int
+	tree.DescendLessOrEqual(40, func(k, v int) bool {
+		got = append(got, k)
+		return true
+	})
+	if want := rangrev(100)[59:]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("descendlessorequal:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+// TestDescendGreaterThan tests iterating over the keys in [last, 40)
+func TestDescendGreaterThan(t *testing.T) {
+	tree := buildTreeWithPerm(btreeDegree, 100)
+
+	var got []int
+	tree.DescendGreaterThan(40, func(k, v int) bool {
+		got = append(got, k)
+		return true
+	})
+	if want := rangrev(100)[:59]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("descendgreaterthan:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+// TestIterateRangeBoundsNotInTree tests range bounds that are not stored in the tree
+func TestIterateRangeBoundsNotInTree(t *testing.T) {
+	tree := NewBeetree[int, int](2)
+	for i := 0; i < 100; i += 2 {
+		tree.Insert(Key[int, int]{K: i})
+	}
+
+	var got []int
+	tree.AscendRange(41, 51, func(k, v int) bool {
+		got = append(got, k)
+		return true
+	})
+	if want := []int{42, 44, 46, 48, 50}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ascendrange:\n got: %v\nwant: %v", got, want)
+	}
+
+	got = got[:0]
+	tree.DescendRange(51, 41, func(k, v int) bool {
+		got = append(got, k)
+		return true
+	})
+	if want := []int{50, 48, 46, 44, 42}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("descendrange:\n got: %v\nwant: %v", got, want)
+	}
+
+	// Empty tree.
+	empty := NewBeetree[int, int](2)
+	empty.Ascend(func(k, v int) bool {
+		t.Fatalf("unexpected key %d in empty tree", k)
+		return true
+	})
+	empty.Descend(func(k, v int) bool {
+		t.Fatalf("unexpected key %d in empty tree", k)
+		return true
+	})
+}
+
+// TestAll tests the range-over-func iterator over all keys
+func TestAll(t *testing.T) {
+	tree := NewBeetree[int, int](2)
+	for _, k := range rand.Perm(100) {
+		tree.Put(k, k*10)
+	}
+
+	var got []int
+	for k, v := range tree.All() {
+		if v != k*10 {
+			t.Errorf("Expected value %d for key %d, got %d", k*10, k, v)
+		}
+		got = append(got, k)
+	}
+	if want := rang(100); !reflect.DeepEqual(got, want) {
+		t.Fatalf("all:\n got: %v\nwant: %v", got, want)
+	}
+
+	// Breaking out of the loop must stop the traversal.
+	got = got[:0]
+	for k := range tree.All() {
+		if k > 50 {
+			break
+		}
+		got = append(got, k)
+	}
+	if want := rang(100)[:51]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("all:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+// TestBackward tests the range-over-func iterator over all keys in reverse order
+func TestBackward(t *testing.T) {
+	tree := buildTreeWithPerm(2, 100)
+
+	var got []int
+	for k := range tree.Backward() {
+		got = append(got, k)
+	}
+	if want := rangrev(100); !reflect.DeepEqual(got, want) {
+		t.Fatalf("backward:\n got: %v\nwant: %v", got, want)
+	}
+
+	got = got[:0]
+	for k := range tree.Backward() {
+		if k < 50 {
+			break
+		}
+		got = append(got, k)
+	}
+	if want := rangrev(100)[:50]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("backward:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+// TestRange tests the range-over-func iterator over the keys in [40, 60)
+func TestRange(t *testing.T) {
+	tree := buildTreeWithPerm(2, 100)
+
+	var got []int
+	for k := range tree.Range(40, 60) {
+		got = append(got, k)
+	}
+	if want := rang(100)[40:60]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("range:\n got: %v\nwant: %v", got, want)
+	}
+
+	got = got[:0]
+	for k := range tree.Range(40, 60) {
+		if k > 50 {
+			break
+		}
+		got = append(got, k)
+	}
+	if want := rang(100)[40:51]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("range:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+// TestLen tests that the number of keys is tracked across inserts, replacements and deletes
+func TestLen(t *testing.T) {
+	tree := NewBeetree[int, int](2)
+	if tree.Len() != 0 {
+		t.Errorf("Expected empty tree to have length 0, got %d", tree.Len())
+	}
+
+	for i, key := range perm(100) {
+		tree.Insert(key)
+		if tree.Len() != i+1 {
+			t.Errorf("Expected length %d, got %d", i+1, tree.Len())
+		}
+	}
+
+	// Replacing keys does not change the length.
+	for _, key := range perm(100) {
+		tree.Insert(key)
+	}
+	if tree.Len() != 100 {
+		t.Errorf("Expected length 100 after duplicates, got %d", tree.Len())
+	}
+
+	// Deleting a missing key does not change the length.
+	tree.Delete(1000)
+	if tree.Len() != 100 {
+		t.Errorf("Expected length 100 after deleting missing key, got %d", tree.Len())
+	}
+
+	for i, key := range perm(100) {
+		tree.Delete(key.K)
+		if tree.Len() != 99-i {
+			t.Errorf("Expected length %d, got %d", 99-i, tree.Len())
+		}
+	}
+}
+
+// TestMinMax tests getting the smallest and largest keys
+func TestMinMax(t *testing.T) {
+	tree := NewBeetree[int, int](2)
+	if _, _, found := tree.Min(); found {
+		t.Errorf("Expected no min in empty tree")
+	}
+	if _, _, found := tree.Max(); found {
+		t.Errorf("Expected no max in empty tree")
+	}
+
+	for _, k := range rand.Perm(100) {
+		tree.Put(k, k*10)
+	}
+
+	if k, v, found := tree.Min(); !found || k != 0 || v != 0 {
+		t.Errorf("Expected min 0 with value 0, got %d with value %d (found %t)", k, v, found)
+	}
+	if k, v, found := tree.Max(); !found || k != 99 || v != 990 {
+		t.Errorf("Expected max 99 with value 990, got %d with value %d (found %t)", k, v, found)
+	}
+}
+
+// TestDeleteMin tests deleting the keys from the smallest one
+func TestDeleteMin(t *testing.T) {
+	tree := buildTreeWithPerm(3, 100)
+
+	var got []int
+	for k, _, found := tree.DeleteMin(); found; k, _, found = tree.DeleteMin() {
+		got = append(got, k)
+		if tree.Root != nil && len(tree.Root.Keys) > 0 {
+			verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
+		}
+	}
+	if want := rang(100); !reflect.DeepEqual(got, want) {
+		t.Fatalf("deletemin:\n got: %v\nwant: %v", got, want)
+	}
+	if tree.Len() != 0 {
+		t.Errorf("Expected empty tree, got length %d", tree.Len())
+	}
+}
+
+// TestDeleteMax tests deleting the keys from the largest one
+func TestDeleteMax(t *testing.T) {
+	tree := buildTreeWithPerm(3, 100)
+
+	var got []int
+	for k, _, found := tree.DeleteMax(); found; k, _, found = tree.DeleteMax() {
+		got = append(got, k)
+		if tree.Root != nil && len(tree.Root.Keys) > 0 {
+			verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
+		}
+	}
+	if want := rangrev(100); !reflect.DeepEqual(got, want) {
+		t.Fatalf("deletemax:\n got: %v\nwant: %v", got, want)
+	}
+	if tree.Len() != 0 {
+		t.Errorf("Expected empty tree, got length %d", tree.Len())
+	}
+}
+
+// TestNeighbors tests Floor, Ceiling, Lower and Higher against a sorted slice of even keys
+func TestNeighbors(t *testing.T) {
+	// neighbor returns the key of the sorted slice closest to pivot by brute force.
+	neighbor := func(keys []int, pivot int, inclusive, above bool) (int, bool) {
+		if above {
+			for _, k := range keys {
+				if k > pivot || inclusive && k == pivot {
+					return k, true
+				}
+			}
+			return 0, false
+		}
+		for i := len(keys) - 1; i >= 0; i-- {
+			if keys[i] < pivot || inclusive && keys[i] == pivot {
+				return keys[i], true
+			}
+		}
+		return 0, false
+	}
+
+	var keys []int
+	for i := 0; i < 50; i++ {
+		keys = append(keys, i*2)
+	}
+
+	for _, degree := range []int{2, 3, 4} {
+		tree := NewBeetree[int, int](degree)
+		for _, i := range rand.Perm(50) {
+			tree.Put(keys[i], keys[i]*10)
+		}
+
+		for pivot := -2; pivot <= 100; pivot++ {
+			for _, tc := range []struct {
+				name             string
+				fn               func(int) (int, int, bool)
+				inclusive, above bool
+			}{
+				{"Floor", tree.Floor, true, false},
+				{"Ceiling", tree.Ceiling, true, true},
+				{"Lower", tree.Lower, false, false},
+				{"Higher", tree.Higher, false, true},
+			} {
+				k, v, found := tc.fn(pivot)
+				want, wantFound := neighbor(keys, pivot, tc.inclusive, tc.above)
+				if found != wantFound || (found && (k != want || v != want*10)) {
+					t.Fatalf("degree %d %s(%d): got %d with value %d (found %t), want %d (found %t)",
+						degree, tc.name, pivot, k, v, found, want, wantFound)
+				}
+			}
+		}
+	}
+
+	empty := NewBeetree[int, int](2)
+	if _, _, found := empty.Floor(1); found {
+		t.Errorf("Expected no floor in empty tree")
+	}
+}
+
+// TestRankSelect tests Rank, Select and CountRange against a sorted slice while keys are inserted and deleted
+func TestRankSelect(t *testing.T) {
+	for _, degree := range []int{2, 3, 5} {
+		tree := NewBeetree[int, int](degree)
+		var keys []int
+
+		for step := 0; step < 2000; step++ {
+			k := rand.Intn(500)
+			i, exists := slices.BinarySearch(keys, k)
+			if rand.Intn(3) == 0 {
+				tree.Delete(k)
+				if exists {
+					keys = slices.Delete(keys, i, i+1)
+				}
+			} else {
+				tree.Put(k, k*10)
+				if !exists {
+					keys = slices.Insert(keys, i, k)
+				}
+			}
+
+			if step%100 != 0 {
+				continue
+			}
+			if tree.Root != nil && len(tree.Root.Keys) > 0 {
+				verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
+			}
+
+			for probe := -1; probe <= 501; probe++ {
+				if got, want := tree.Rank(probe), sort.SearchInts(keys, probe); got != want {
+					t.Fatalf("degree %d Rank(%d): got %d, want %d", degree, probe, got, want)
+				}
+			}
+
+			for i, want := range keys {
+				k, v, found := tree.Select(i)
+				if !found || k != want || v != want*10 {
+					t.Fatalf("degree %d Select(%d): got %d with value %d (found %t), want %d", degree, i, k, v, found, want)
+				}
+			}
+			if _, _, found := tree.Select(len(keys)); found {
+				t.Fatalf("degree %d Select(%d): expected rank out of range", degree, len(keys))
+			}
+			if _, _, found := tree.Select(-1); found {
+				t.Fatalf("degree %d Select(-1): expected rank out of range", degree)
+			}
+
+			lo, hi := rand.Intn(500), rand.Intn(500)
+			want := 0
+			for _, k := range keys {
+				if k >= lo && k < hi {
+					want++
+				}
+			}
+			if got := tree.CountRange(lo, hi); got != want {
+				t.Fatalf("degree %d CountRange(%d, %d): got %d, want %d", degree, lo, hi, got, want)
+			}
+		}
+	}
+}
+
+// Helper function to collect all keys and values from the tree in order
+func collectPairs(tree *BeeTree[int, int]) map[int]int {
+	pairs := make(map[int]int)
+	for k, v := range tree.All() {
+		pairs[k] = v
+	}
+	return pairs
+}
+
+// TestCloneIsolation tests that writes to a clone and to its original do not affect each other
+func TestCloneIsolation(t *testing.T) {
+	tree := NewBeetree[int, int](2)
+	for _, k := range rand.Perm(200) {
+		tree.Put(k, k)
+	}
+	before := collectPairs(tree)
+
+	clone := tree.Clone()
+
+	// Write to the clone: replace even keys, delete keys multiple of 3 and add new keys.
+	for k := 0; k < 200; k += 2 {
+		clone.Put(k, -k)
+	}
+	for k := 0; k < 200; k += 3 {
+		clone.Delete(k)
+	}
+	for k := 200; k < 300; k++ {
+		clone.Put(k, k)
+	}
+
+	// The original must be unchanged.
+	if got := collectPairs(tree); !reflect.DeepEqual(got, before) {
+		t.Fatalf("original tree changed after writing to the clone")
+	}
+	if tree.Len() != 200 {
+		t.Errorf("Expected original length 200, got %d", tree.Len())
+	}
+	verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
+
+	// Write to the original and check the clone is unchanged.
+	cloneBefore := collectPairs(clone)
+	for _, k := range rand.Perm(200) {
+		tree.Delete(k)
+	}
+	if got := collectPairs(clone); !reflect.DeepEqual(got, cloneBefore) {
+		t.Fatalf("clone changed after writing to the original tree")
+	}
+	verifyBTreeProperties(t, clone, clone.Root, clone.Degree, true)
+
+	for k := 0; k < 300; k++ {
+		v, found := clone.Get(k)
+		switch {
+		case k < 200 && k%3 == 0:
+			if found {
+				t.Errorf("Expected key %d to be deleted from clone", k)
+			}
+		case k < 200 && k%2 == 0:
+			if !found || v != -k {
+				t.Errorf("Expected clone value %d for key %d, got %d (found %t)", -k, k, v, found)
+			}
+		default:
+			if !found || v != k {
+				t.Errorf("Expected clone value %d for key %d, got %d (found %t)", k, k, v, found)
+			}
+		}
+	}
+}
+
+// TestCloneDeleteMissingKeySharesNodes tests that deleting a key that is not in a clone does not
+// copy any node of the clone
+func TestCloneDeleteMissingKeySharesNodes(t *testing.T) {
+	tree := buildTreeWithPerm(2, 1000)
+	tree.Delete(500)
+	clone := tree.Clone()
+
+	for _, k := range []int{-1, 500, 1000} {
+		if _, found := clone.Delete(k); found {
+			t.Fatalf("Key %d should not have been found", k)
+		}
+		if clone.Root != tree.Root {
+			t.Errorf("Deleting missing key %d copied the root of the clone", k)
+		}
+	}
+}
+
+// TestCloneConcurrentOperations tests writing to clones of a tree from different goroutines
+func TestCloneConcurrentOperations(t *testing.T) {
+	tree := buildTreeWithPerm(3, 1000)
+
+	var wg sync.WaitGroup
+	clones := make([]*BeeTree[int, int], 8)
+	for i := range clones {
+		clones[i] = tree.Clone()
+	}
+
+	for i, clone := range clones {
+		wg.Add(1)
+		go func(i int, clone *BeeTree[int, int]) {
+			defer wg.Done()
+			// Every clone deletes a different residue and moves the rest.
+			for k := 0; k < 1000; k++ {
+				if k%len(clones) == i {
+					clone.Delete(k)
+				} else {
+					clone.Put(k, k+i)
+				}
+			}
+		}(i, clone)
+	}
+	wg.Wait()
+
+	for i, clone := range clones {
+		verifyBTreeProperties(t, clone, clone.Root, clone.Degree, true)
+		for k := 0; k < 1000; k++ {
+			v, found := clone.Get(k)
+			if k%len(clones) == i {
+				if found {
+					t.Fatalf("clone %d: expected key %d to be deleted", i, k)
+				}
+			} else if !found || v != k+i {
+				t.Fatalf("clone %d: expected value %d for key %d, got %d (found %t)", i, k+i, k, v, found)
+			}
+		}
+	}
+
+	if tree.Len() != 1000 {
+		t.Errorf("Expected original length 1000, got %d", tree.Len())
+	}
+	for k, v := range tree.All() {
+		if v != 0 {
+			t.Fatalf("original tree changed: key %d has value %d", k, v)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package beetree
+
+import (
+	"cmp"
+	"iter"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// BLinkTree is a B-link tree, the btree variant from Lehman and Yao, that is safe
+// for concurrent use by multiple goroutines. Readers never block on writers.
+//
+// Keys and values are stored in the leaf nodes and the inner nodes only hold
+// separator keys. Every node has a high key, the upper bound of the keys that
+// can be stored in it, and a link to its right sibling. When a node is split,
+// the keys bigger than the middle key move to a new right sibling before the
+// parent node knows about it, so a reader that arrives to a node through a stale
+// path sees a key above the high key and follows the right link instead.
+//
+// The content of a node is immutable once published. Writers latch the node,
+// build a new version of its content and publish it atomically, so readers load
+// the current version of every node without taking any latch.
+//
+// As in the original paper, deleting keys never merges nodes. A node can be left
+// with fewer keys than the minimum, or even empty, which keeps lookups correct
+// without having to coordinate merges with concurrent readers.
+type BLinkTree[K, V any] struct {
+	// mu serializes the growth of the btree by a new root node.
+	mu   sync.Mutex
+	root atomic.Pointer[blinkNode[K, V]]
+
+	degree  int
+	length  atomic.Int64
+	compare func(a, b K) int
+}
+
+type blinkNode[K, V any] struct {
+	// mu is held by the writers modifying the node. Readers don't take it.
+	mu   sync.Mutex
+	page atomic.Pointer[blinkPage[K, V]]
+}
+
+// blinkPage is a version of the content of a node. It is never modified once
+// published.
+type blinkPage[K, V any] struct {
+	// keys are the keys of a leaf node or the separator keys of an inner node,
+	// where the child at index i holds the keys in [keys[i-1], keys[i]).
+	keys     []K
+	values   []V
+	children []*blinkNode[K, V]
+
+	// high is the exclusive upper bound of the keys of the node, nil for the
+	// rightmost node of every level.
+	high  *K
+	right *blinkNode[K, V]
+	level int
+}
+
+// NewBLinkTree creates a BLinkTree of the given degree for keys with a natural
+// ordering.
+func NewBLinkTree[K cmp.Ordered, V any](degree int) *BLinkTree[K, V] {
+	return NewBLinkTreeFunc[K, V](degree, cmp.Compare[K])
+}
+
+// NewBLinkTreeFunc creates a BLinkTree of the given degree whose keys are ordered
+// by compare.
+func NewBLinkTreeFunc[K, V any](degree int, compare func(a, b K) int) *BLinkTree[K, V] {
+	if degree < 2 {
+		panic("beetree: degree must be at least 2")
+	}
+
+	bt := &BLinkTree[K, V]{
+		degree:  degree,
+		compare: compare,
+	}
+	root := &blinkNode[K, V]{}
+	root.page.Store(&blinkPage[K, V]{})
+	bt.root.Store(root)
+	return bt
+}
+
+// Len returns the number of keys stored in the btree.
+func (bt *BLinkTree[K, V]) Len() int {
+	return int(bt.length.Load())
+}
+
+// Get returns the value stored for the key. The second value reports whether
+// the key was found.
+func (bt *BLinkTree[K, V]) Get(key K) (V, bool) {
+	node := bt.root.Load()
+	page := node.page.Load()
+
+	for {
+		if bt.beyond(page, key) {
+			node = page.right
+			page = node.page.Load()
+			continue
+		}
+
+		if page.level == 0 {
+			if i, found := bt.search(page, key); found {
+				return page.values[i], true
+			}
+			var zero V
+			return zero, false
+		}
+
+		node = page.children[bt.childIndex(page, key)]
+		page = node.page.Load()
+	}
+}
+
+// Has reports whether the key is stored in the btree.
+func (bt *BLinkTree[K, V]) Has(key K) bool {
+	_, found := bt.Get(key)
+	return found
+}
+
+// All returns an iterator over all the keys and values of the btree in ascending
+// order. The iteration follows the right links of the leaf nodes, so it sees every
+// leaf node as it is when the iteration arrives to it.
+func (bt *BLinkTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		page := bt.root.Load().page.Load()
+		for page.level > 0 {
+			page = page.children[0].page.Load()
+		}
+
+		var last *K
+		for {
+			for i, k := range page.keys {
+				// A leaf node split after the previous leaf node was loaded moves
+				// keys that were already seen to the right.
+				if last != nil && bt.compare(k, *last) <= 0 {
+					continue
+				}
+				if !yield(k, page.values[i]) {
+					return
+				}
+				last = &page.keys[i]
+			}
+
+			if page.right == nil {
+				return
+			}
+			page = page.right.page.Load()
+		}
+	}
+}
+
+// Put adds the key with the given value to the btree. If the key already exists,
+// its value is replaced and the previous value is returned together with true.
+func (bt *BLinkTree[K, V]) Put(key K, value V) (V, bool) {
+	stack := bt.descend(key)
+
+	node := stack[len(stack)-1]
+	stack = stack[:len(stack)-1]
+	node, page := bt.lockAndMoveRight(node, key)
+
+	i, found := bt.search(page, key)
+	if found {
+		old := page.values[i]
+		newPage := page.clone()
+		newPage.values = clonedWith(page.values, i, value)
+		node.page.Store(newPage)
+		node.mu.Unlock()
+		return old, true
+	}
+
+	newPage := page.clone()
+	newPage.keys = insertedAt(page.keys, i, key)
+	newPage.values = insertedAt(page.values, i, value)
+	bt.length.Add(1)
+
+	// Splits are propagated bottom up, latching the parent node before releasing
+	// the latch of its split child.
+	for len(newPage.keys) > bt.maxKeys() {
+		separator, right := bt.split(newPage)
+		node.page.Store(newPage)
+
+		parent := bt.parent(&stack, node, newPage.level)
+		if parent == nil {
+			node.mu.Unlock()
+			var zero V
+			return zero, false
+		}
+
+		parent, parentPage := bt.lockAndMoveRight(parent, separator)
+		node.mu.Unlock()
+		node = parent
+
+		j := bt.childIndex(parentPage, separator)
+		newPage = parentPage.clone()
+		newPage.keys = insertedAt(parentPage.keys, j, separator)
+		newPage.children = insertedAt(parentPage.children, j+1, right)
+	}
+
+	node.page.Store(newPage)
+	node.mu.Unlock()
+	var zero V
+	return zero, false
+}
+
+// Delete deletes a key from the btree if found and returns its value. The second
+// value reports whether the key was found.
+func (bt *BLinkTree[K, V]) Delete(key K) (V, bool) {
+	stack := bt.descend(key)
+	node, page := bt.lockAndMoveRight(stack[len(stack)-1], key)
+	defer node.mu.Unlock()
+
+	i, found := bt.search(page, key)
+	if !found {
+		var zero V
+		return zero, false
+	}
+
+	newPage := page.clone()
+	newPage.keys = removedAt(page.keys, i)
+	newPage.values = removedAt(page.values, i)
+	node.page.Store(newPage)
+	bt.length.Add(-1)
+	return page.values[i], true
+}
+
+// descend returns the nodes visited from the root node to the leaf node where the
+// key belongs. It does not take any latch, so the path can become stale.
+func (bt *BLinkTree[K, V]) descend(key K) []*blinkNode[K, V] {
+	var stack []*blinkNode[K, V]
+
+	node := bt.root.Load()
+	page := node.page.Load()
+	for {
+		if bt.beyond(page, key) {
+			node = page.right
+			page = node.page.Load()
+			continue
+		}
+
+		stack = append(stack, node)
+		if page.level == 0 {
+			return stack
+		}
+
+		node = page.children[bt.childIndex(page, key)]
+		page = node.page.Load()
+	}
+}
+
+// lockAndMoveRight latches the node and follows the right links, latching a node
+// before releasing the previous one, until it arrives to the node where the key
+// belongs. It returns that node latched and its content.
+func (bt *BLinkTree[K, V]) lockAndMoveRight(node *blinkNode[K, V], key K) (*blinkNode[K, V], *blinkPage[K, V]) {
+	node.mu.Lock()
+	page := node.page.Load()
+	for bt.beyond(page, key) {
+		right := page.right
+		right.mu.Lock()
+		node.mu.Unlock()
+		node = right
+		page = node.page.Load()
+	}
+
+	return node, page
+}
+
+// parent returns the node of the next level up where the separator of the split
+// node has to be inserted. It returns nil if the split node was the root node, in
+// which case the btree has grown a new root node.
+func (bt *BLinkTree[K, V]) parent(stack *[]*blinkNode[K, V], node *blinkNode[K, V], level int) *blinkNode[K, V] {
+	if len(*stack) > 0 {
+		parent := (*stack)[len(*stack)-1]
+		*stack = (*stack)[:len(*stack)-1]
+		return parent
+	}
+
+	for {
+		bt.mu.Lock()
+		root := bt.root.Load()
+		rootPage := root.page.Load()
+
+		if root == node {
+			// The split node is the root node, since it is latched it can't have
+			// been split by anyone else.
+			page := node.page.Load()
+			newRoot := &blinkNode[K, V]{}
+			newRoot.page.Store(&blinkPage[K, V]{
+				keys:     []K{*page.high},
+				children: []*blinkNode[K, V]{node, page.right},
+				level:    level + 1,
+			})
+			bt.root.Store(newRoot)
+			bt.mu.Unlock()
+			return nil
+		}
+
+		if rootPage.level > level {
+			// The btree grew while the path was walked. The leftmost node of the
+			// level above is a valid starting point since the right links lead to
+			// the parent node.
+			parent := root
+			for page := rootPage; page.level > level+1; page = parent.page.Load() {
+				parent = page.children[0]
+			}
+			bt.mu.Unlock()
+			return parent
+		}
+
+		// The root node was split but its split has not grown the btree yet.
+		bt.mu.Unlock()
+		runtime.Gosched()
+	}
+}
+
+// split moves the upper half of the overflowed page to a new right sibling and
+// returns the separator key between both nodes. The page is updated to be the left
+// half, linked to the new node.
+func (bt *BLinkTree[K, V]) split(page *blinkPage[K, V]) (K, *blinkNode[K, V]) {
+	middle := len(page.keys) / 2
+
+	rightPage := &blinkPage[K, V]{
+		high:  page.high,
+		right: page.right,
+		level: page.level,
+	}
+
+	var separator K
+	if page.level == 0 {
+		// Leaf nodes keep every key, the separator is a copy of the first key of
+		// the right node.
+		rightPage.keys = append([]K(nil), page.keys[middle:]...)
+		rightPage.values = append([]V(nil), page.values[middle:]...)
+		separator = page.keys[middle]
+		page.keys = append([]K(nil), page.keys[:middle]...)
+		page.values = append([]V(nil), page.values[:middle]...)
+	} else {
+		// Inner nodes move the middle separator up.
+		rightPage.keys = append([]K(nil), page.keys[middle+1:]...)
+		rightPage.children = append([]*blinkNode[K, V](nil), page.children[middle+1:]...)
+		separator = page.keys[middle]
+		page.keys = append([]K(nil), page.keys[:middle]...)
+		page.children = append([]*blinkNode[K, V](nil), page.children[:middle+1]...)
+	}
+
+	// The right node is published before the left node links to it.
+	right := &blinkNode[K, V]{}
+	right.page.Store(rightPage)
+
+	page.high = &separator
+	page.right = right
+	return separator, right
+}
+
+// beyond reports whether the key is above the high key of the page, meaning it
+// belongs to a node on the right.
+func (bt *BLinkTree[K, V]) beyond(page *blinkPage[K, V], key K) bool {
+	return page.high != nil && bt.compare(key, *page.high) >= 0
+}
+
+// search returns the index of the key if found in the page, otherwise it returns
+// the index where the key would be inserted.
+func (bt *BLinkTree[K, V]) search(page *blinkPage[K, V], key K) (int, bool) {
+	for i, k := range page.keys {
+		c := bt.compare(key, k)
+		if c == 0 {
+			return i, true
+		}
+		if c < 0 {
+			return i, false
+		}
+	}
+
+	return len(page.keys), false
+}
+
+// childIndex returns the index of the child of an inner page where the key belongs.
+func (bt *BLinkTree[K, V]) childIndex(page *blinkPage[K, V], key K) int {
+	i := 0
+	for i < len(page.keys) && bt.compare(key, page.keys[i]) >= 0 {
+		i++
+	}
+	return i
+}
+
+func (bt *BLinkTree[K, V]) maxKeys() int {
+	return 2*bt.degree - 1
+}
+
+// clone returns a shallow copy of the page that can be modified before it is
+// published.
+func (p *blinkPage[K, V]) clone() *blinkPage[K, V] {
+	c := *p
+	return &c
+}
+
+// insertedAt returns a copy of s with v inserted at index i.
+func insertedAt[T any](s []T, i int, v T) []T {
+	result := make([]T, 0, len(s)+1)
+	result = append(result, s[:i]...)
+	result = append(result, v)
+	return append(result, s[i:]...)
+}
+
+// removedAt returns a copy of s without the element at index i.
+func removedAt[T any](s []T, i int) []T {
+	result := make([]T, 0, len(s)-1)
+	result = append(result, s[:i]...)
+	return append(result, s[i+1:]...)
+}
+
+// clonedWith returns a copy of s with the element at index i replaced by v.
+func clonedWith[T any](s []T, i int, v T) []T {
+	result := append([]T(nil), s...)
+	result[i] = v
+	return result
+}
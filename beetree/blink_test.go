@@ -0,0 +1,203 @@
+package beetree
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// verifyBLinkTree checks the B-link tree invariants and returns the number of keys in it
+func verifyBLinkTree(t *testing.T, tree *BLinkTree[int, int]) int {
+	t.Helper()
+
+	count := 0
+	first := tree.root.Load()
+	for level := first.page.Load().level; level >= 0; level-- {
+		// Every level is a linked list of nodes with increasing keys, where the
+		// high key of a node bounds its keys and the keys of its right sibling.
+		var low *int
+		node := first
+		for node != nil {
+			page := node.page.Load()
+			if page.level != level {
+				t.Fatalf("Node at level %d, expected %d", page.level, level)
+			}
+			if len(page.keys) > tree.maxKeys() {
+				t.Errorf("Node has %d keys, maximum allowed: %d", len(page.keys), tree.maxKeys())
+			}
+			for i, k := range page.keys {
+				if (i > 0 && page.keys[i-1] >= k) || (low != nil && k < *low) || (page.high != nil && k >= *page.high) {
+					t.Errorf("Key %d is out of order at level %d", k, level)
+				}
+			}
+			if (page.high == nil) != (page.right == nil) {
+				t.Errorf("Only the rightmost node of a level can have no high key")
+			}
+
+			if level == 0 {
+				count += len(page.keys)
+			} else if len(page.children) != len(page.keys)+1 {
+				t.Fatalf("Node has %d children, expected %d", len(page.children), len(page.keys)+1)
+			}
+			low = page.high
+			node = page.right
+		}
+
+		if level > 0 {
+			first = first.page.Load().children[0]
+		}
+	}
+
+	return count
+}
+
+// TestBLinkTreeRandomOperations tests the BLinkTree against a map from a single goroutine
+func TestBLinkTreeRandomOperations(t *testing.T) {
+	for _, degree := range []int{2, 3, 5} {
+		r := rand.New(rand.NewSource(int64(degree)))
+		tree := NewBLinkTree[int, int](degree)
+		expected := map[int]int{}
+
+		for i := 0; i < 5000; i++ {
+			k := r.Intn(500)
+			if r.Intn(3) == 0 {
+				v, found := tree.Delete(k)
+				ev, efound := expected[k]
+				if found != efound || v != ev {
+					t.Fatalf("degree %d: Delete(%d) = %d, %t, expected %d, %t", degree, k, v, found, ev, efound)
+				}
+				delete(expected, k)
+			} else {
+				old, replaced := tree.Put(k, i)
+				ev, efound := expected[k]
+				if replaced != efound || old != ev {
+					t.Fatalf("degree %d: Put(%d) = %d, %t, expected %d, %t", degree, k, old, replaced, ev, efound)
+				}
+				expected[k] = i
+			}
+		}
+
+		if count := verifyBLinkTree(t, tree); count != len(expected) || tree.Len() != len(expected) {
+			t.Errorf("degree %d: Expected %d keys, got %d (length %d)", degree, len(expected), count, tree.Len())
+		}
+		for k, ev := range expected {
+			if v, found := tree.Get(k); !found || v != ev {
+				t.Errorf("degree %d: Expected value %d for key %d, got %d (found %t)", degree, ev, k, v, found)
+			}
+		}
+
+		prev := -1
+		count := 0
+		for k, v := range tree.All() {
+			if k <= prev || v != expected[k] {
+				t.Errorf("degree %d: Unexpected key %d with value %d after key %d", degree, k, v, prev)
+			}
+			prev = k
+			count++
+		}
+		if count != len(expected) {
+			t.Errorf("degree %d: Expected to iterate over %d keys, got %d", degree, len(expected), count)
+		}
+	}
+}
+
+// TestBLinkTreeReadsSeeCompletedInserts tests that every key inserted before a read begins is
+// visible to that read, while other goroutines keep splitting nodes and deleting keys.
+// Run it with -race to detect unsynchronized accesses.
+func TestBLinkTreeReadsSeeCompletedInserts(t *testing.T) {
+	const writers, readers, keysPerWriter = 4, 4, 3000
+
+	tree := NewBLinkTree[int, int](2)
+
+	// Keys below deletedBase are inserted and deleted again by a separate goroutine
+	// so that reads also race with deletions.
+	const deletedBase = -100000
+
+	// inserted[w] is the number of keys the writer w has finished inserting.
+	var inserted [writers]atomic.Int64
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < keysPerWriter; i++ {
+				tree.Put(i*writers+w, w)
+				inserted[w].Store(int64(i + 1))
+			}
+		}(w)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < keysPerWriter; i++ {
+			tree.Put(deletedBase+i, i)
+			if i%3 != 0 {
+				tree.Delete(deletedBase + i)
+			}
+		}
+	}()
+
+	done := make(chan struct{})
+	var readersWg sync.WaitGroup
+	for r := 0; r < readers; r++ {
+		readersWg.Add(1)
+		go func(r int) {
+			defer readersWg.Done()
+			for n := 0; ; n++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if r == 0 && n%50 == 0 {
+					// A scan sees every key inserted before it began.
+					var before [writers]int64
+					for w := range before {
+						before[w] = inserted[w].Load()
+					}
+					seen := map[int]bool{}
+					for k := range tree.All() {
+						seen[k] = true
+					}
+					for w, count := range before {
+						for i := 0; i < int(count); i++ {
+							if !seen[i*writers+w] {
+								t.Errorf("Key %d inserted before the scan began was not seen", i*writers+w)
+								return
+							}
+						}
+					}
+					continue
+				}
+
+				w := rand.Intn(writers)
+				count := inserted[w].Load()
+				if count == 0 {
+					continue
+				}
+				k := rand.Intn(int(count))*writers + w
+				if v, found := tree.Get(k); !found || v != w {
+					t.Errorf("Key %d inserted before the read began was not found", k)
+					return
+				}
+			}
+		}(r)
+	}
+
+	wg.Wait()
+	close(done)
+	readersWg.Wait()
+
+	expected := writers*keysPerWriter + keysPerWriter/3
+	if count := verifyBLinkTree(t, tree); count != expected || tree.Len() != expected {
+		t.Errorf("Expected %d keys, got %d (length %d)", expected, count, tree.Len())
+	}
+}
+
+func BenchmarkBLinkTreeParallelMixed(b *testing.B) {
+	benchmarkParallelMixed(b, NewBLinkTree[int, int](16))
+}
@@ -0,0 +1,119 @@
+package beetree
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+)
+
+// ErrUnsorted is returned by BulkLoad when its input is not in strictly
+// ascending order.
+var ErrUnsorted = errors.New("beetree: keys are not in strictly ascending order")
+
+// BulkLoad replaces the content of the btree with the keys and values of seq,
+// which must yield the keys in strictly ascending order. The btree is built
+// bottom-up in O(n), instead of the O(n log n) of inserting every key.
+//
+// fillFactor is the fraction of the 2*Degree-1 keys of a node that is used,
+// between 0 and 1. A btree loaded with a fill factor of 1 is the smallest one,
+// but the first inserts split its nodes; a lower fill factor leaves room for
+// them. No node gets less than Degree-1 keys, whatever the fill factor.
+//
+// If seq yields a key that is not greater than the previous one, BulkLoad
+// returns ErrUnsorted and leaves the btree unchanged.
+func (bt *BeeTree[K, V]) BulkLoad(seq iter.Seq2[K, V], fillFactor float64) error {
+	if !(fillFactor > 0 && fillFactor <= 1) {
+		return fmt.Errorf("beetree: fill factor %v is not in (0, 1]", fillFactor)
+	}
+	maxKeys := 2*bt.Degree - 1
+	keysPerNode := max(int(math.Round(fillFactor*float64(maxKeys))), bt.Degree-1)
+
+	var keys []Key[K, V]
+	for k, v := range seq {
+		if len(keys) > 0 && bt.compare(keys[len(keys)-1].K, k) >= 0 {
+			return fmt.Errorf("%w: key %v at position %d follows %v", ErrUnsorted, k, len(keys), keys[len(keys)-1].K)
+		}
+		keys = append(keys, Key[K, V]{K: k, V: v})
+	}
+
+	bt.Root = bt.build(keys, keysPerNode)
+	bt.length = len(keys)
+	bt.version++
+	return nil
+}
+
+// build creates the nodes of a btree holding the sorted keys, with about
+// keysPerNode keys in every node, and returns its root. It runs in O(n).
+//
+// keysPerNode must be between Degree-1 and 2*Degree-1. The nodes never go
+// below Degree-1 keys, so some nodes may be fuller when there are few keys.
+func (bt *BeeTree[K, V]) build(keys []Key[K, V], keysPerNode int) *Node[K, V] {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// Every leaf but the last is followed by a key that separates it from the next
+	// leaf, so n keys fill l leaves with n-(l-1) keys between them.
+	leaves := bt.nodesForLevel(len(keys)+1, keysPerNode+1)
+	nodes := make([]*Node[K, V], 0, leaves)
+	separators := make([]Key[K, V], 0, leaves-1)
+	leafKeys := len(keys) - (leaves - 1)
+	start := 0
+	for i := 0; i < leaves; i++ {
+		n := leafKeys / leaves
+		if i < leafKeys%leaves {
+			n++
+		}
+
+		leaf := bt.newNode()
+		leaf.Keys = append(leaf.Keys, keys[start:start+n]...)
+		leaf.size = n
+		nodes = append(nodes, leaf)
+		start += n
+		if i < leaves-1 {
+			separators = append(separators, keys[start])
+			start++
+		}
+	}
+
+	// Every level groups the nodes of the level below as children. The separators
+	// between two children of the same node become its keys, the others are left
+	// to separate the nodes of the next level.
+	for len(nodes) > 1 {
+		parents := bt.nodesForLevel(len(nodes), keysPerNode+1)
+		nextNodes := make([]*Node[K, V], 0, parents)
+		nextSeparators := make([]Key[K, V], 0, parents-1)
+		start := 0
+		for i := 0; i < parents; i++ {
+			n := len(nodes) / parents
+			if i < len(nodes)%parents {
+				n++
+			}
+
+			parent := bt.newNode()
+			parent.Children = append(parent.Children, nodes[start:start+n]...)
+			parent.Keys = append(parent.Keys, separators[start:start+n-1]...)
+			parent.updateSize()
+			nextNodes = append(nextNodes, parent)
+			start += n
+			if i < parents-1 {
+				nextSeparators = append(nextSeparators, separators[start-1])
+			}
+		}
+		nodes, separators = nextNodes, nextSeparators
+	}
+
+	return nodes[0]
+}
+
+// nodesForLevel returns the number of nodes sharing the given number of
+// children, or of keys plus one for leaves, so that they have about
+// childrenPerNode each and between Degree and 2*Degree. A single node is the
+// root, which can have fewer.
+func (bt *BeeTree[K, V]) nodesForLevel(children, childrenPerNode int) int {
+	nodes := (children + childrenPerNode - 1) / childrenPerNode
+	nodes = max(nodes, (children+2*bt.Degree-1)/(2*bt.Degree))
+	nodes = min(nodes, children/bt.Degree)
+	return max(nodes, 1)
+}
@@ -0,0 +1,175 @@
+package beetree
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// sortedKeys returns the keys in the range [0, n) in order, with the value of every key being its
+// negation
+func sortedKeys(n int) []Key[int, int] {
+	keys := make([]Key[int, int], n)
+	for i := range keys {
+		keys[i] = Key[int, int]{K: i, V: -i}
+	}
+	return keys
+}
+
+// verifyLeafDepth checks that all the leaves of the tree are at the same depth
+func verifyLeafDepth(t *testing.T, tree *BeeTree[int, int]) {
+	t.Helper()
+
+	depths := map[int]bool{}
+	var walk func(node *Node[int, int], depth int)
+	walk = func(node *Node[int, int], depth int) {
+		if len(node.Children) == 0 {
+			depths[depth] = true
+		}
+		for _, child := range node.Children {
+			walk(child, depth+1)
+		}
+	}
+	if tree.Root != nil {
+		walk(tree.Root, 0)
+	}
+	if len(depths) > 1 {
+		t.Errorf("Leaves are at different depths: %v", depths)
+	}
+}
+
+// TestBuild tests that building a tree from sorted keys creates a valid tree for any number of keys
+// and of keys per node
+func TestBuild(t *testing.T) {
+	for degree := 2; degree <= 5; degree++ {
+		for keysPerNode := degree - 1; keysPerNode <= 2*degree-1; keysPerNode++ {
+			for n := 0; n <= 700; n++ {
+				tree := NewBeetree[int, int](degree)
+				tree.Root = tree.build(sortedKeys(n), keysPerNode)
+
+				verifyBTreeProperties(t, tree, tree.Root, degree, true)
+				verifyLeafDepth(t, tree)
+				if keys := collectKeysInOrder(tree.Root); !reflect.DeepEqual(keys, rang(n)) && n > 0 {
+					t.Fatalf("Degree %d, %d keys per node, %d keys: unexpected keys %v", degree, keysPerNode, n, keys)
+				}
+				if t.Failed() {
+					t.Fatalf("Degree %d, %d keys per node, %d keys: invalid tree", degree, keysPerNode, n)
+				}
+			}
+		}
+	}
+}
+
+// leafFill returns the average number of keys of the leaves of the tree
+func leafFill(tree *BeeTree[int, int]) float64 {
+	leaves, keys := 0, 0
+	var walk func(node *Node[int, int])
+	walk = func(node *Node[int, int]) {
+		if len(node.Children) == 0 {
+			leaves++
+			keys += len(node.Keys)
+		}
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(tree.Root)
+	return float64(keys) / float64(leaves)
+}
+
+// TestBulkLoad tests that a bulk loaded tree has the keys and values of the input and can be
+// modified like any other
+func TestBulkLoad(t *testing.T) {
+	for _, fillFactor := range []float64{0.01, 0.5, 0.75, 1} {
+		for _, n := range []int{0, 1, 2, 10, 1000} {
+			pairs := map[int]int{}
+			seq := func(yield func(int, int) bool) {
+				for i := 0; i < n; i++ {
+					pairs[i*2] = -i
+					if !yield(i*2, -i) {
+						return
+					}
+				}
+			}
+
+			tree := NewBeetree[int, int](3)
+			tree.Put(-1, -1)
+			if err := tree.BulkLoad(seq, fillFactor); err != nil {
+				t.Fatalf("BulkLoad failed: %v", err)
+			}
+			if tree.Len() != n || !reflect.DeepEqual(collectPairs(tree), pairs) {
+				t.Fatalf("Fill factor %v, %d keys: unexpected keys after BulkLoad", fillFactor, n)
+			}
+			verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
+
+			for i := 0; i < n; i++ {
+				tree.Put(i*2+1, i)
+			}
+			for i := 0; i < n; i += 2 {
+				tree.Delete(i * 2)
+			}
+			if err := tree.Verify(); err != nil {
+				t.Fatalf("Fill factor %v, %d keys: Verify failed after writes: %v", fillFactor, n, err)
+			}
+		}
+	}
+}
+
+// TestBulkLoadFillFactor tests that the fill factor decides how full the leaves are
+func TestBulkLoadFillFactor(t *testing.T) {
+	tree := NewBeetree[int, int](4)
+	for _, c := range []struct {
+		fillFactor float64
+		keys       int
+	}{{0.1, 3}, {0.5, 4}, {0.75, 5}, {1, 7}} {
+		if err := tree.BulkLoad(slices.All(rang(10000)), c.fillFactor); err != nil {
+			t.Fatalf("BulkLoad failed: %v", err)
+		}
+		if fill := leafFill(tree); fill < float64(c.keys)-0.1 || fill > float64(c.keys)+0.1 {
+			t.Errorf("Fill factor %v: expected %d keys per leaf, got %.2f", c.fillFactor, c.keys, fill)
+		}
+	}
+}
+
+// TestBulkLoadRejectsUnsortedInput tests that unsorted or duplicated keys are rejected without
+// modifying the tree
+func TestBulkLoadRejectsUnsortedInput(t *testing.T) {
+	for name, keys := range map[string][]int{
+		"unsorted":   {1, 2, 4, 3, 5},
+		"duplicated": {1, 2, 3, 3, 4},
+	} {
+		tree := NewBeetree[int, int](2)
+		tree.Put(-1, -1)
+
+		seq := func(yield func(int, int) bool) {
+			for _, k := range keys {
+				if !yield(k, k) {
+					return
+				}
+			}
+		}
+		if err := tree.BulkLoad(seq, 1); !errors.Is(err, ErrUnsorted) {
+			t.Errorf("%s: expected ErrUnsorted, got %v", name, err)
+		}
+		if tree.Len() != 1 || !tree.Has(-1) {
+			t.Errorf("%s: tree was modified by a failed BulkLoad", name)
+		}
+	}
+
+	tree := NewBeetree[int, int](2)
+	for _, fillFactor := range []float64{0, -1, 1.5} {
+		if err := tree.BulkLoad(slices.All(rang(10)), fillFactor); err == nil {
+			t.Errorf("Expected an error for fill factor %v", fillFactor)
+		}
+	}
+}
+
+func BenchmarkBulkLoad(b *testing.B) {
+	keys := rang(benchmarkTreeSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree := NewBeetree[int, int](btreeDegree)
+		tree.BulkLoad(slices.All(keys), 1)
+	}
+}
@@ -0,0 +1,102 @@
+package beetree
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Codec encodes values of type T into a fixed number of bytes, so they can be
+// stored in the fixed-size pages of a DiskTree.
+type Codec[T any] interface {
+	// Size returns the number of bytes of every encoded value.
+	Size() int
+	// Encode writes v into dst, which has Size bytes.
+	Encode(dst []byte, v T) error
+	// Decode reads a value from src, which has Size bytes.
+	Decode(src []byte) (T, error)
+}
+
+// IntCodec encodes an int in 8 bytes.
+type IntCodec struct{}
+
+func (IntCodec) Size() int { return 8 }
+
+func (IntCodec) Encode(dst []byte, v int) error {
+	binary.LittleEndian.PutUint64(dst, uint64(v))
+	return nil
+}
+
+func (IntCodec) Decode(src []byte) (int, error) {
+	return int(int64(binary.LittleEndian.Uint64(src))), nil
+}
+
+// Int64Codec encodes an int64 in 8 bytes.
+type Int64Codec struct{}
+
+func (Int64Codec) Size() int { return 8 }
+
+func (Int64Codec) Encode(dst []byte, v int64) error {
+	binary.LittleEndian.PutUint64(dst, uint64(v))
+	return nil
+}
+
+func (Int64Codec) Decode(src []byte) (int64, error) {
+	return int64(binary.LittleEndian.Uint64(src)), nil
+}
+
+// Uint64Codec encodes an uint64 in 8 bytes.
+type Uint64Codec struct{}
+
+func (Uint64Codec) Size() int { return 8 }
+
+func (Uint64Codec) Encode(dst []byte, v uint64) error {
+	binary.LittleEndian.PutUint64(dst, v)
+	return nil
+}
+
+func (Uint64Codec) Decode(src []byte) (uint64, error) {
+	return binary.LittleEndian.Uint64(src), nil
+}
+
+// Float64Codec encodes a float64 in 8 bytes.
+type Float64Codec struct{}
+
+func (Float64Codec) Size() int { return 8 }
+
+func (Float64Codec) Encode(dst []byte, v float64) error {
+	binary.LittleEndian.PutUint64(dst, math.Float64bits(v))
+	return nil
+}
+
+func (Float64Codec) Decode(src []byte) (float64, error) {
+	return math.Float64frombits(binary.LittleEndian.Uint64(src)), nil
+}
+
+// StringCodec encodes strings of up to MaxLen bytes in MaxLen+2 bytes, the length
+// of the string followed by its bytes.
+type StringCodec struct {
+	MaxLen int
+}
+
+func (c StringCodec) Size() int { return c.MaxLen + 2 }
+
+func (c StringCodec) Encode(dst []byte, v string) error {
+	if len(v) > c.MaxLen || len(v) > math.MaxUint16 {
+		return fmt.Errorf("beetree: string of %d bytes is longer than %d bytes", len(v), c.MaxLen)
+	}
+
+	binary.LittleEndian.PutUint16(dst, uint16(len(v)))
+	n := copy(dst[2:], v)
+	clear(dst[2+n:])
+	return nil
+}
+
+func (c StringCodec) Decode(src []byte) (string, error) {
+	n := int(binary.LittleEndian.Uint16(src))
+	if n > c.MaxLen {
+		return "", fmt.Errorf("%w: string of %d bytes is longer than %d bytes", ErrCorrupt, n, c.MaxLen)
+	}
+
+	return string(src[2 : 2+n]), nil
+}
@@ -0,0 +1,151 @@
+package beetree
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Stats describes the pages of a DiskTree file.
+type Stats struct {
+	// PageSize is the size in bytes of every page.
+	PageSize int
+	// Pages is the number of pages of the file, the meta page included.
+	Pages int
+	// LivePages is the number of pages holding nodes of the btree.
+	LivePages int
+	// FreePages is the number of pages in the free list, to be reused by new nodes.
+	FreePages int
+	// Keys is the number of keys stored in the btree.
+	Keys int
+}
+
+// Stats returns the page usage of the btree file.
+func (dt *DiskTree[K, V]) Stats() Stats {
+	return Stats{
+		PageSize:  dt.meta.pageSize,
+		Pages:     int(dt.meta.pageCount),
+		LivePages: int(dt.meta.pageCount - 1 - dt.meta.freeCount),
+		FreePages: int(dt.meta.freeCount),
+		Keys:      int(dt.meta.length),
+	}
+}
+
+// Compact rewrites the btree file without free pages, shrinking it to the pages
+// holding nodes. The nodes are stored in breadth first order, so nodes of the same
+// level are next to each other.
+//
+// The new file is written next to the btree file and renamed over it once it is
+// complete, so a crash leaves either the old or the new file. The btree can still
+// be used after Compact returns.
+func (dt *DiskTree[K, V]) Compact() error {
+	if err := dt.writable(); err != nil {
+		return err
+	}
+	if err := dt.Checkpoint(); err != nil {
+		return err
+	}
+
+	tmpPath := dt.path + ".compact"
+	m, err := dt.writeCompacted(tmpPath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, dt.path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := syncDir(filepath.Dir(dt.path)); err != nil {
+		return err
+	}
+
+	// The btree switches to the new file. Any failure from here on leaves it
+	// unusable, but the file on disk is complete.
+	f, err := dt.openStorage(dt.path)
+	if err != nil {
+		dt.closed = true
+		return errors.Join(err, dt.file.file.Close(), dt.wal.file.Close())
+	}
+	if err := dt.file.file.Close(); err != nil {
+		dt.closed = true
+		return errors.Join(err, f.Close(), dt.wal.file.Close())
+	}
+
+	dt.file.file = f
+	dt.meta = m
+	dt.pool = newBufferPool(dt.pool.capacity, dt.readNode, dt.writeNode)
+	return nil
+}
+
+// writeCompacted writes the nodes of the btree to consecutive pages of a new file
+// at path and returns its meta page.
+func (dt *DiskTree[K, V]) writeCompacted(path string) (meta, error) {
+	m := dt.meta
+	m.root = 0
+	m.pageCount = 1
+	m.freeHead = 0
+	m.freeCount = 0
+
+	f, err := dt.openStorage(path)
+	if err != nil {
+		return m, err
+	}
+	defer f.Close()
+
+	// A file left by a previous Compact that crashed may be bigger.
+	if err := f.Truncate(0); err != nil {
+		return m, err
+	}
+
+	page := make([]byte, dt.meta.pageSize)
+	if dt.meta.root != 0 {
+		// Page ids are given in the order the nodes are queued, which is the order
+		// they are written.
+		m.root = 1
+		m.pageCount = 2
+		queue := []pageID{dt.meta.root}
+
+		for newID := pageID(1); len(queue) > 0; newID++ {
+			node, err := dt.pool.fetch(queue[0])
+			if err != nil {
+				return m, err
+			}
+			queue = queue[1:]
+
+			moved := diskNode[K, V]{id: newID, entries: entries[K, V, pageID]{keys: node.keys}}
+			for _, child := range node.children {
+				moved.children = append(moved.children, pageID(m.pageCount))
+				queue = append(queue, child)
+				m.pageCount++
+			}
+			dt.pool.unpin(node)
+
+			if err := dt.encodeNode(&moved, page); err != nil {
+				return m, err
+			}
+			if _, err := f.WriteAt(page, int64(newID)*int64(len(page))); err != nil {
+				return m, fmt.Errorf("beetree: writing compacted page %d: %w", newID, err)
+			}
+		}
+	}
+
+	m.encode(page)
+	if _, err := f.WriteAt(page, 0); err != nil {
+		return m, fmt.Errorf("beetree: writing compacted meta page: %w", err)
+	}
+	return m, f.Sync()
+}
+
+// syncDir flushes the directory to stable storage, so a rename in it is durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
@@ -0,0 +1,237 @@
+package beetree
+
+// Cursor is a stateful position over the keys of a BeeTree that can be moved
+// forward and backward.
+//
+// The cursor keeps the path from the root to its current key. Any modification
+// of the tree invalidates that path, so moving a cursor or reading its key after
+// the tree was modified panics. First, Last and Seek can be used to position the
+// cursor again.
+type Cursor[K, V any] struct {
+	tree    *BeeTree[K, V]
+	version uint64
+
+	// stack holds the path from the root to the current key. For every node but
+	// the last one, index is the index of the child the path continues to. For
+	// the last node, index is the index of the current key.
+	stack []cursorFrame[K, V]
+}
+
+type cursorFrame[K, V any] struct {
+	node  *Node[K, V]
+	index int
+}
+
+// Cursor returns a new cursor over the btree. The cursor is not positioned, so
+// First, Last or Seek must be called before reading keys.
+func (bt *BeeTree[K, V]) Cursor() *Cursor[K, V] {
+	return &Cursor[K, V]{
+		tree:    bt,
+		version: bt.version,
+	}
+}
+
+// Valid reports whether the cursor is positioned at a key.
+func (c *Cursor[K, V]) Valid() bool {
+	c.checkVersion()
+	return len(c.stack) > 0
+}
+
+// Key returns the key at the current position of the cursor.
+func (c *Cursor[K, V]) Key() K {
+	return c.current().K
+}
+
+// Value returns the value at the current position of the cursor.
+func (c *Cursor[K, V]) Value() V {
+	return c.current().V
+}
+
+// First moves the cursor to the smallest key of the btree. It returns false if
+// the btree is empty.
+func (c *Cursor[K, V]) First() bool {
+	c.reset()
+	if c.tree.Root == nil {
+		return false
+	}
+
+	c.pushLeftmost(c.tree.Root)
+	return c.positioned()
+}
+
+// Last moves the cursor to the largest key of the btree. It returns false if the
+// btree is empty.
+func (c *Cursor[K, V]) Last() bool {
+	c.reset()
+	if c.tree.Root == nil {
+		return false
+	}
+
+	c.pushRightmost(c.tree.Root)
+	return c.positioned()
+}
+
+// Seek moves the cursor to the first key that is greater or equal than key. It
+// returns false if there is no such key.
+func (c *Cursor[K, V]) Seek(key K) bool {
+	c.reset()
+
+	node := c.tree.Root
+	for node != nil {
+		indexOfKey, indexOfChild := node.findIndexOfKey(key, c.tree.compare)
+		if indexOfKey >= 0 {
+			c.stack = append(c.stack, cursorFrame[K, V]{node, indexOfKey})
+			return true
+		}
+
+		c.stack = append(c.stack, cursorFrame[K, V]{node, indexOfChild})
+		if len(node.Children) == 0 {
+			break
+		}
+		node = node.Children[indexOfChild]
+	}
+
+	// The key would be stored at the end of the leaf, so the next key is in one
+	// of the parent nodes.
+	if len(c.stack) > 0 && c.stack[len(c.stack)-1].index >= len(c.stack[len(c.stack)-1].node.Keys) {
+		c.popToNext()
+	}
+
+	return c.positioned()
+}
+
+// Next moves the cursor to the next key in ascending order. It returns false if
+// there is no next key, leaving the cursor unpositioned.
+func (c *Cursor[K, V]) Next() bool {
+	if !c.Valid() {
+		return false
+	}
+
+	top := &c.stack[len(c.stack)-1]
+	if len(top.node.Children) > 0 {
+		// The next key is the smallest key of the right child.
+		top.index++
+		c.pushLeftmost(top.node.Children[top.index])
+		return true
+	}
+
+	top.index++
+	if top.index < len(top.node.Keys) {
+		return true
+	}
+
+	c.popToNext()
+	return c.positioned()
+}
+
+// Prev moves the cursor to the previous key in ascending order. It returns false
+// if there is no previous key, leaving the cursor unpositioned.
+func (c *Cursor[K, V]) Prev() bool {
+	if !c.Valid() {
+		return false
+	}
+
+	top := &c.stack[len(c.stack)-1]
+	if len(top.node.Children) > 0 {
+		// The previous key is the largest key of the left child.
+		c.pushRightmost(top.node.Children[top.index])
+		return true
+	}
+
+	top.index--
+	if top.index >= 0 {
+		return true
+	}
+
+	c.popToPrev()
+	return c.positioned()
+}
+
+func (c *Cursor[K, V]) current() Key[K, V] {
+	if !c.Valid() {
+		panic("beetree: cursor is not positioned at a key")
+	}
+
+	top := c.stack[len(c.stack)-1]
+	return top.node.Keys[top.index]
+}
+
+// checkVersion panics if the btree was modified after the cursor was positioned.
+func (c *Cursor[K, V]) checkVersion() {
+	if c.version != c.tree.version {
+		panic("beetree: btree modified while using cursor")
+	}
+}
+
+func (c *Cursor[K, V]) reset() {
+	c.version = c.tree.version
+	c.stack = c.stack[:0]
+}
+
+// positioned drops the path if it does not end at a key, which happens when the
+// btree has no keys.
+func (c *Cursor[K, V]) positioned() bool {
+	if len(c.stack) == 0 {
+		return false
+	}
+
+	top := c.stack[len(c.stack)-1]
+	if top.index < 0 || top.index >= len(top.node.Keys) {
+		c.stack = c.stack[:0]
+		return false
+	}
+
+	return true
+}
+
+// pushLeftmost adds the path from node to its smallest key.
+func (c *Cursor[K, V]) pushLeftmost(node *Node[K, V]) {
+	for {
+		c.stack = append(c.stack, cursorFrame[K, V]{node, 0})
+		if len(node.Children) == 0 {
+			return
+		}
+		node = node.Children[0]
+	}
+}
+
+// pushRightmost adds the path from node to its largest key.
+func (c *Cursor[K, V]) pushRightmost(node *Node[K, V]) {
+	for {
+		if len(node.Children) == 0 {
+			c.stack = append(c.stack, cursorFrame[K, V]{node, len(node.Keys) - 1})
+			return
+		}
+		c.stack = append(c.stack, cursorFrame[K, V]{node, len(node.Keys)})
+		node = node.Children[len(node.Keys)]
+	}
+}
+
+// popToNext removes the exhausted leaf from the path and moves up until it finds
+// a parent whose key follows the child the path went through.
+func (c *Cursor[K, V]) popToNext() {
+	c.stack = c.stack[:len(c.stack)-1]
+	for len(c.stack) > 0 {
+		// The index of the child is also the index of the key on its right.
+		top := c.stack[len(c.stack)-1]
+		if top.index < len(top.node.Keys) {
+			return
+		}
+		c.stack = c.stack[:len(c.stack)-1]
+	}
+}
+
+// popToPrev removes the exhausted leaf from the path and moves up until it finds
+// a parent whose key precedes the child the path went through.
+func (c *Cursor[K, V]) popToPrev() {
+	c.stack = c.stack[:len(c.stack)-1]
+	for len(c.stack) > 0 {
+		top := &c.stack[len(c.stack)-1]
+		if top.index > 0 {
+			// The key on the left of the child is at the index of the child minus one.
+			top.index--
+			return
+		}
+		c.stack = c.stack[:len(c.stack)-1]
+	}
+}
@@ -0,0 +1,125 @@
+package beetree
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestCursorForwardAndBackward tests walking all keys with Next and Prev
+func TestCursorForwardAndBackward(t *testing.T) {
+	for _, degree := range []int{2, 3, 5} {
+		tree := buildTreeWithPerm(degree, 200)
+		cursor := tree.Cursor()
+
+		var got []int
+		for ok := cursor.First(); ok; ok = cursor.Next() {
+			got = append(got, cursor.Key())
+		}
+		if want := rang(200); !reflect.DeepEqual(got, want) {
+			t.Fatalf("degree %d next:\n got: %v\nwant: %v", degree, got, want)
+		}
+
+		got = got[:0]
+		for ok := cursor.Last(); ok; ok = cursor.Prev() {
+			got = append(got, cursor.Key())
+		}
+		if want := rangrev(200); !reflect.DeepEqual(got, want) {
+			t.Fatalf("degree %d prev:\n got: %v\nwant: %v", degree, got, want)
+		}
+	}
+}
+
+// TestCursorSeek tests positioning the cursor at the first key greater or equal than a key
+func TestCursorSeek(t *testing.T) {
+	tree := NewBeetree[int, int](2)
+	for i := 0; i < 100; i += 2 {
+		tree.Put(i, i*10)
+	}
+	cursor := tree.Cursor()
+
+	for i := -1; i < 99; i++ {
+		want := i
+		if i < 0 {
+			want = 0
+		} else if i%2 == 1 {
+			want = i + 1
+		}
+
+		if !cursor.Seek(i) {
+			t.Fatalf("Expected seek of %d to find a key", i)
+		}
+		if cursor.Key() != want || cursor.Value() != want*10 {
+			t.Errorf("Seek(%d): expected key %d, got %d with value %d", i, want, cursor.Key(), cursor.Value())
+		}
+	}
+
+	if cursor.Seek(99) {
+		t.Errorf("Expected seek past the largest key to fail, got %d", cursor.Key())
+	}
+	if cursor.Valid() {
+		t.Errorf("Expected cursor to not be positioned")
+	}
+
+	// Move in both directions from the sought key.
+	cursor.Seek(51)
+	cursor.Prev()
+	cursor.Prev()
+	if cursor.Key() != 48 {
+		t.Errorf("Expected key 48, got %d", cursor.Key())
+	}
+	cursor.Next()
+	cursor.Next()
+	cursor.Next()
+	if cursor.Key() != 54 {
+		t.Errorf("Expected key 54, got %d", cursor.Key())
+	}
+}
+
+// TestCursorEmptyTree tests a cursor over trees without keys
+func TestCursorEmptyTree(t *testing.T) {
+	tree := NewBeetree[int, int](2)
+	cursor := tree.Cursor()
+	if cursor.First() || cursor.Last() || cursor.Seek(0) || cursor.Next() || cursor.Prev() {
+		t.Errorf("Expected cursor over empty tree to not be positioned")
+	}
+
+	// Root without keys.
+	tree.Put(1, 1)
+	tree.Delete(1)
+	if cursor.First() || cursor.Last() || cursor.Seek(0) {
+		t.Errorf("Expected cursor over tree without keys to not be positioned")
+	}
+}
+
+// TestCursorDetectsModification tests that using a cursor after modifying the tree panics
+func TestCursorDetectsModification(t *testing.T) {
+	tree := buildTreeWithPerm(2, 50)
+	cursor := tree.Cursor()
+	cursor.Seek(10)
+
+	tree.Delete(20)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected Next to panic after the tree was modified")
+		}
+	}()
+	cursor.Next()
+}
+
+// TestCursorRepositionAfterModification tests that a cursor can be positioned again after a modification
+func TestCursorRepositionAfterModification(t *testing.T) {
+	tree := buildTreeWithPerm(2, 50)
+	cursor := tree.Cursor()
+	cursor.Seek(10)
+
+	tree.Delete(11)
+
+	if !cursor.Seek(10) {
+		t.Fatalf("Expected seek to find key 10")
+	}
+	cursor.Next()
+	if cursor.Key() != 12 {
+		t.Errorf("Expected key 12 after deleting 11, got %d", cursor.Key())
+	}
+}
@@ -0,0 +1,998 @@
+package beetree
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+)
+
+// Options configures how a DiskTree file is opened.
+type Options struct {
+	// PageSize is the size in bytes of the pages of a new file, DefaultPageSize if
+	// zero. An existing file keeps the page size it was created with, opening it
+	// with a different non zero page size fails.
+	PageSize int
+
+	// CacheSize is the number of pages kept in memory, DefaultCacheSize if zero.
+	CacheSize int
+
+	// CheckpointSize is the size in bytes the WAL grows to before its pages are
+	// written to the btree file, DefaultCheckpointSize if zero.
+	CheckpointSize int
+
+	// NoSync skips flushing the WAL to stable storage after every write. Writes
+	// are still atomic, but the last ones can be lost on a crash unless Sync is
+	// called.
+	NoSync bool
+
+	// ReadOnly opens the file in read-only mode, where the file is mapped in
+	// memory and reads are served straight from the mapped pages. Writes fail with
+	// ErrReadOnly. The file must have been closed by its last writer.
+	ReadOnly bool
+
+	// openFile opens the btree file and the WAL, os.OpenFile if nil.
+	openFile func(path string) (storageFile, error)
+}
+
+// DiskTree is a btree persisted in a single file. Every node is stored in a
+// fixed-size page, so keys and values are encoded with fixed-size codecs and the
+// degree of the btree is the largest one whose nodes fit in a page.
+//
+// Nodes are loaded on demand through a buffer pool that keeps the most recently
+// used ones in memory.
+//
+// Every write is atomic and durable. The pages it modifies are appended to a
+// write-ahead log (WAL), the file at path with the "-wal" suffix, and flushed to
+// stable storage before the write returns. Modified pages are written to the btree
+// file when they are evicted from the pool or on a checkpoint, which happens when
+// the WAL grows past Options.CheckpointSize and on Close. Open replays the WAL
+// left by a crash, so the btree has every write that returned.
+//
+// Insertions split full nodes and deletions fill minimal nodes on the way down,
+// as in CLRS, so every node is visited once and the btree is valid after every
+// step, even if an operation fails half way with an I/O error.
+//
+// A DiskTree is not safe for concurrent use by multiple goroutines, except in
+// read-only mode where it only reads the mapped file.
+type DiskTree[K, V any] struct {
+	path        string
+	openStorage func(path string) (storageFile, error)
+
+	file   pageFile
+	wal    *wal
+	pool   *bufferPool[K, V]
+	meta   meta
+	closed bool
+
+	// failed is the error of a commit that could not be logged. The buffer pool
+	// holds pages that are not in the WAL, so the btree is not used anymore.
+	failed error
+
+	checkpointSize int64
+	noSync         bool
+
+	degree     int
+	keyCodec   Codec[K]
+	valueCodec Codec[V]
+	compare    func(a, b K) int
+
+	// scratch is a page sized buffer used to encode pages.
+	scratch []byte
+
+	// mapped is the file mapped in memory in read-only mode.
+	mapped []byte
+}
+
+// Open opens the DiskTree stored in the file at path, creating the file if it does
+// not exist, for keys with a natural ordering.
+func Open[K cmp.Ordered, V any](path string, keyCodec Codec[K], valueCodec Codec[V], opts *Options) (*DiskTree[K, V], error) {
+	return OpenFunc(path, keyCodec, valueCodec, cmp.Compare[K], opts)
+}
+
+// OpenFunc opens the DiskTree stored in the file at path, creating the file if it
+// does not exist, whose keys are ordered by compare.
+func OpenFunc[K, V any](path string, keyCodec Codec[K], valueCodec Codec[V], compare func(a, b K) int, opts *Options) (*DiskTree[K, V], error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if opts.ReadOnly {
+		return openMapped(path, keyCodec, valueCodec, compare, opts)
+	}
+
+	cacheSize := opts.CacheSize
+	if cacheSize == 0 {
+		cacheSize = DefaultCacheSize
+	}
+	if cacheSize < minCacheSize {
+		return nil, fmt.Errorf("beetree: cache size must be at least %d pages", minCacheSize)
+	}
+
+	openStorage := opts.openFile
+	if openStorage == nil {
+		openStorage = func(path string) (storageFile, error) {
+			return os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+		}
+	}
+
+	f, err := openStorage(path)
+	if err != nil {
+		return nil, err
+	}
+	walFile, err := openStorage(path + "-wal")
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	w := &wal{file: walFile, synced: true}
+	if err := recoverWAL(w, f); err != nil {
+		f.Close()
+		walFile.Close()
+		return nil, err
+	}
+
+	dt, created, err := openFile(f, keyCodec, valueCodec, compare, opts)
+	if err != nil {
+		f.Close()
+		walFile.Close()
+		return nil, err
+	}
+
+	dt.path = path
+	dt.openStorage = openStorage
+	dt.wal = w
+	dt.checkpointSize = int64(opts.CheckpointSize)
+	if dt.checkpointSize == 0 {
+		dt.checkpointSize = DefaultCheckpointSize
+	}
+	dt.noSync = opts.NoSync
+	dt.pool = newBufferPool(cacheSize, dt.readNode, dt.writeNode)
+
+	// The meta page of a new file is written through the WAL too, so a crash can't
+	// leave a partial meta page behind.
+	if created {
+		dt.meta.encode(dt.scratch)
+		w.appendPage(0, dt.scratch)
+		err := w.commit(true)
+		if err == nil {
+			err = dt.Checkpoint()
+		}
+		if err != nil {
+			f.Close()
+			walFile.Close()
+			return nil, err
+		}
+	}
+
+	return dt, nil
+}
+
+// openFile reads the meta page of the file, or the options for an empty file, and
+// checks that it matches the codecs and options. It reports whether the file is
+// empty.
+func openFile[K, V any](f storageFile, keyCodec Codec[K], valueCodec Codec[V], compare func(a, b K) int, opts *Options) (*DiskTree[K, V], bool, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+
+	var m meta
+	if info.Size() == 0 {
+		m = meta{
+			pageSize:  opts.PageSize,
+			keySize:   keyCodec.Size(),
+			valueSize: valueCodec.Size(),
+			pageCount: 1,
+		}
+		if m.pageSize == 0 {
+			m.pageSize = DefaultPageSize
+		}
+		if m.pageSize < minPageSize || m.pageSize > maxPageSize {
+			return nil, false, fmt.Errorf("beetree: page size must be between %d and %d bytes", minPageSize, maxPageSize)
+		}
+	} else {
+		if m, err = readMeta(f); err != nil {
+			return nil, false, err
+		}
+		if opts.PageSize != 0 && opts.PageSize != m.pageSize {
+			return nil, false, fmt.Errorf("beetree: file has page size %d, not %d", m.pageSize, opts.PageSize)
+		}
+		if m.keySize != keyCodec.Size() || m.valueSize != valueCodec.Size() {
+			return nil, false, fmt.Errorf("beetree: file has keys of %d bytes and values of %d bytes, codecs have %d and %d",
+				m.keySize, m.valueSize, keyCodec.Size(), valueCodec.Size())
+		}
+	}
+
+	degree := degreeForPage(m.pageSize, m.keySize+m.valueSize)
+	if degree < 2 {
+		return nil, false, fmt.Errorf("beetree: page size %d is too small for entries of %d bytes", m.pageSize, m.keySize+m.valueSize)
+	}
+	// The number of keys of a node is stored in 16 bits.
+	if 2*degree-1 > math.MaxUint16 {
+		return nil, false, fmt.Errorf("beetree: page size %d is too large for entries of %d bytes", m.pageSize, m.keySize+m.valueSize)
+	}
+
+	dt := &DiskTree[K, V]{
+		file:       pageFile{file: f, pageSize: m.pageSize},
+		meta:       m,
+		degree:     degree,
+		keyCodec:   keyCodec,
+		valueCodec: valueCodec,
+		compare:    compare,
+		scratch:    make([]byte, m.pageSize),
+	}
+
+	return dt, info.Size() == 0, nil
+}
+
+// degreeForPage returns the largest degree t whose nodes, with up to 2t-1 entries
+// and 2t children, fit in a page.
+func degreeForPage(pageSize, entrySize int) int {
+	// nodeHeaderSize + (2t-1)*entrySize + 2t*8 + 4 <= pageSize
+	return (pageSize - nodeHeaderSize - 4 + entrySize) / (2*entrySize + 16)
+}
+
+// Degree returns the degree of the btree.
+func (dt *DiskTree[K, V]) Degree() int {
+	return dt.degree
+}
+
+// Len returns the number of keys stored in the btree.
+func (dt *DiskTree[K, V]) Len() int {
+	return int(dt.meta.length)
+}
+
+// Sync flushes the WAL to stable storage, making every write durable. It is only
+// needed with Options.NoSync, otherwise every write is durable when it returns.
+func (dt *DiskTree[K, V]) Sync() error {
+	if err := dt.usable(); err != nil {
+		return err
+	}
+	if dt.mapped != nil {
+		return nil
+	}
+
+	return dt.wal.sync()
+}
+
+// Checkpoint writes every modified page and the meta page to the btree file,
+// flushes it to stable storage and empties the WAL.
+func (dt *DiskTree[K, V]) Checkpoint() error {
+	if err := dt.writable(); err != nil {
+		return err
+	}
+
+	// The WAL is flushed first, since pages can only be written to the btree file
+	// once they are logged.
+	if err := dt.wal.sync(); err != nil {
+		return err
+	}
+	if err := dt.pool.flush(); err != nil {
+		return err
+	}
+	if err := dt.writeMeta(); err != nil {
+		return err
+	}
+	if err := dt.file.file.Sync(); err != nil {
+		return err
+	}
+	return dt.wal.reset()
+}
+
+// Close checkpoints the btree and closes its files. After a failed write the
+// btree is not checkpointed, the next Open recovers it from the WAL.
+func (dt *DiskTree[K, V]) Close() error {
+	if dt.closed {
+		return ErrClosed
+	}
+	if dt.mapped != nil {
+		dt.closed = true
+		return errors.Join(munmap(dt.mapped), dt.file.file.Close())
+	}
+	if dt.failed != nil {
+		dt.closed = true
+		return errors.Join(dt.file.file.Close(), dt.wal.file.Close())
+	}
+
+	err := dt.Checkpoint()
+	dt.closed = true
+	return errors.Join(err, dt.file.file.Close(), dt.wal.file.Close())
+}
+
+// commit logs the pages modified by the operation in progress and the meta page
+// in the WAL, which makes the operation durable. If they can't be logged, the
+// btree fails: the modified pages must never reach the btree file.
+func (dt *DiskTree[K, V]) commit() error {
+	nodes := dt.pool.modified()
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	for _, node := range nodes {
+		if err := dt.encodeNode(node, dt.scratch); err != nil {
+			return dt.fail(err)
+		}
+		dt.wal.appendPage(node.id, dt.scratch)
+	}
+	dt.meta.encode(dt.scratch)
+	dt.wal.appendPage(0, dt.scratch)
+
+	if err := dt.wal.commit(!dt.noSync); err != nil {
+		return dt.fail(err)
+	}
+	dt.pool.commit()
+
+	if dt.wal.size >= dt.checkpointSize {
+		return dt.Checkpoint()
+	}
+	return nil
+}
+
+// Get returns the value stored for the key. The second value reports whether
+// the key was found.
+func (dt *DiskTree[K, V]) Get(key K) (V, bool, error) {
+	var zero V
+	if err := dt.usable(); err != nil {
+		return zero, false, err
+	}
+	if dt.mapped != nil {
+		return dt.mappedGet(key)
+	}
+
+	id := dt.meta.root
+	for id != 0 {
+		node, err := dt.pool.fetch(id)
+		if err != nil {
+			return zero, false, err
+		}
+		i, found := node.find(key, dt.compare)
+		dt.pool.unpin(node)
+
+		if found {
+			return node.keys[i].V, true, nil
+		}
+		if node.isLeaf() {
+			break
+		}
+		id = node.children[i]
+	}
+
+	return zero, false, nil
+}
+
+// Has reports whether the key is stored in the btree.
+func (dt *DiskTree[K, V]) Has(key K) (bool, error) {
+	_, found, err := dt.Get(key)
+	return found, err
+}
+
+// Put adds the key with the given value to the btree. If the key already exists,
+// its value is replaced and the previous value is returned together with true.
+func (dt *DiskTree[K, V]) Put(key K, value V) (V, bool, error) {
+	if err := dt.writable(); err != nil {
+		var zero V
+		return zero, false, err
+	}
+
+	old, replaced, err := dt.put(key, value)
+	return old, replaced, errors.Join(err, dt.commit())
+}
+
+func (dt *DiskTree[K, V]) put(key K, value V) (V, bool, error) {
+	var zero V
+
+	// Keys and values that can't be encoded are rejected before the btree changes.
+	if err := dt.encodeEntry(dt.scratch, Key[K, V]{K: key, V: value}); err != nil {
+		return zero, false, err
+	}
+
+	node, err := dt.rootForInsert()
+	if err != nil {
+		return zero, false, err
+	}
+
+	for {
+		i, found := node.find(key, dt.compare)
+		if found {
+			old := node.keys[i].V
+			node.keys[i].V = value
+			dt.pool.markDirty(node)
+			dt.pool.unpin(node)
+			return old, true, nil
+		}
+
+		if node.isLeaf() {
+			node.insertKeyAt(i, Key[K, V]{K: key, V: value})
+			dt.pool.markDirty(node)
+			dt.pool.unpin(node)
+			dt.meta.length++
+			return zero, false, nil
+		}
+
+		child, err := dt.pool.fetch(node.children[i])
+		if err != nil {
+			dt.pool.unpin(node)
+			return zero, false, err
+		}
+
+		if len(child.keys) == dt.maxKeys() {
+			sibling, err := dt.splitChild(node, i, child)
+			if err != nil {
+				dt.pool.unpin(child)
+				dt.pool.unpin(node)
+				return zero, false, err
+			}
+
+			switch c := dt.compare(key, node.keys[i].K); {
+			case c == 0:
+				dt.pool.unpin(child)
+				dt.pool.unpin(sibling)
+				continue
+			case c > 0:
+				dt.pool.unpin(child)
+				child = sibling
+			default:
+				dt.pool.unpin(sibling)
+			}
+		}
+
+		dt.pool.unpin(node)
+		node = child
+	}
+}
+
+// rootForInsert returns the pinned root node, creating it if the btree is empty
+// and splitting it if it is full.
+func (dt *DiskTree[K, V]) rootForInsert() (*diskNode[K, V], error) {
+	if dt.meta.root == 0 {
+		root, err := dt.newNode()
+		if err != nil {
+			return nil, err
+		}
+		dt.meta.root = root.id
+		return root, nil
+	}
+
+	root, err := dt.pool.fetch(dt.meta.root)
+	if err != nil {
+		return nil, err
+	}
+	if len(root.keys) < dt.maxKeys() {
+		return root, nil
+	}
+
+	newRoot, err := dt.newNode()
+	if err != nil {
+		dt.pool.unpin(root)
+		return nil, err
+	}
+	newRoot.children = append(newRoot.children, root.id)
+
+	sibling, err := dt.splitChild(newRoot, 0, root)
+	dt.pool.unpin(root)
+	if err != nil {
+		// The new root is not referenced by the btree yet, its page goes back to
+		// the free list.
+		dt.freeNode(newRoot)
+		return nil, err
+	}
+	dt.pool.unpin(sibling)
+
+	dt.meta.root = newRoot.id
+	return newRoot, nil
+}
+
+// splitChild splits the full child at index i of node. The middle key of the child
+// moves to node and the keys bigger than the middle key move to a new right
+// sibling, which is returned pinned.
+func (dt *DiskTree[K, V]) splitChild(node *diskNode[K, V], i int, child *diskNode[K, V]) (*diskNode[K, V], error) {
+	sibling, err := dt.newNode()
+	if err != nil {
+		return nil, err
+	}
+
+	node.splitChild(i, &child.entries, &sibling.entries, sibling.id, dt.degree)
+
+	dt.pool.markDirty(node)
+	dt.pool.markDirty(child)
+	return sibling, nil
+}
+
+// Delete deletes a key from the btree if found and returns its value. The second
+// value reports whether the key was found.
+func (dt *DiskTree[K, V]) Delete(key K) (V, bool, error) {
+	if err := dt.writable(); err != nil {
+		var zero V
+		return zero, false, err
+	}
+
+	old, found, err := dt.delete(key)
+	return old, found, errors.Join(err, dt.commit())
+}
+
+func (dt *DiskTree[K, V]) delete(key K) (V, bool, error) {
+	var zero V
+	if dt.meta.root == 0 {
+		return zero, false, nil
+	}
+
+	node, err := dt.pool.fetch(dt.meta.root)
+	if err != nil {
+		return zero, false, err
+	}
+
+	for {
+		i, found := node.find(key, dt.compare)
+
+		if node.isLeaf() {
+			var deleted Key[K, V]
+			if found {
+				deleted = node.removeKeyAt(i)
+				dt.pool.markDirty(node)
+				dt.meta.length--
+			}
+
+			// The last key of the btree was deleted.
+			if node.id == dt.meta.root && len(node.keys) == 0 {
+				dt.meta.root = 0
+				dt.freeNode(node)
+			} else {
+				dt.pool.unpin(node)
+			}
+			return deleted.V, found, nil
+		}
+
+		var next *diskNode[K, V]
+		if found {
+			deleted := node.keys[i]
+
+			// Replace the key with its predecessor or successor when the child it is
+			// taken from has keys to spare.
+			left, err := dt.pool.fetch(node.children[i])
+			if err != nil {
+				dt.pool.unpin(node)
+				return zero, false, err
+			}
+			if len(left.keys) > dt.minKeys() {
+				return deleted.V, true, dt.replaceKey(node, i, left, dt.removeMax)
+			}
+
+			right, err := dt.pool.fetch(node.children[i+1])
+			if err != nil {
+				dt.pool.unpin(left)
+				dt.pool.unpin(node)
+				return zero, false, err
+			}
+			if len(right.keys) > dt.minKeys() {
+				dt.pool.unpin(left)
+				return deleted.V, true, dt.replaceKey(node, i, right, dt.removeMin)
+			}
+
+			// Both children are minimal, so they are merged around the key and the
+			// deletion continues in the merged node.
+			dt.mergeChildren(node, i, left, right)
+			next = left
+		} else {
+			next, err = dt.fillChild(node, i)
+			if err != nil {
+				dt.pool.unpin(node)
+				return zero, false, err
+			}
+		}
+
+		// The root lost its last key in a merge, so its only child becomes the root.
+		if node.id == dt.meta.root && len(node.keys) == 0 {
+			dt.meta.root = next.id
+			dt.freeNode(node)
+		} else {
+			dt.pool.unpin(node)
+		}
+		node = next
+	}
+}
+
+// replaceKey replaces the key at index i of node with the key removed from the
+// subtree rooted at child. Both nodes are unpinned.
+func (dt *DiskTree[K, V]) replaceKey(node *diskNode[K, V], i int, child *diskNode[K, V], remove func(*diskNode[K, V]) (Key[K, V], error)) error {
+	defer dt.pool.unpin(node)
+
+	key, err := remove(child)
+	if err != nil {
+		return err
+	}
+
+	node.keys[i] = key
+	dt.pool.markDirty(node)
+	dt.meta.length--
+	return nil
+}
+
+// removeMax removes and returns the largest key of the subtree rooted at the
+// pinned node, which must have more than the minimum number of keys. The node is
+// unpinned.
+func (dt *DiskTree[K, V]) removeMax(node *diskNode[K, V]) (Key[K, V], error) {
+	for !node.isLeaf() {
+		child, err := dt.fillChild(node, len(node.children)-1)
+		dt.pool.unpin(node)
+		if err != nil {
+			return Key[K, V]{}, err
+		}
+		node = child
+	}
+
+	key := node.removeKeyAt(len(node.keys) - 1)
+	dt.pool.markDirty(node)
+	dt.pool.unpin(node)
+	return key, nil
+}
+
+// removeMin removes and returns the smallest key of the subtree rooted at the
+// pinned node, which must have more than the minimum number of keys. The node is
+// unpinned.
+func (dt *DiskTree[K, V]) removeMin(node *diskNode[K, V]) (Key[K, V], error) {
+	for !node.isLeaf() {
+		child, err := dt.fillChild(node, 0)
+		dt.pool.unpin(node)
+		if err != nil {
+			return Key[K, V]{}, err
+		}
+		node = child
+	}
+
+	key := node.removeKeyAt(0)
+	dt.pool.markDirty(node)
+	dt.pool.unpin(node)
+	return key, nil
+}
+
+// fillChild returns the pinned child at index i of node after making sure it has
+// more than the minimum number of keys, by borrowing a key from one of its
+// siblings or by merging it with one of them.
+func (dt *DiskTree[K, V]) fillChild(node *diskNode[K, V], i int) (*diskNode[K, V], error) {
+	child, err := dt.pool.fetch(node.children[i])
+	if err != nil {
+		return nil, err
+	}
+	if len(child.keys) > dt.minKeys() {
+		return child, nil
+	}
+
+	// Borrow from the left sibling through the parent key.
+	if i > 0 {
+		left, err := dt.pool.fetch(node.children[i-1])
+		if err != nil {
+			dt.pool.unpin(child)
+			return nil, err
+		}
+		if len(left.keys) > dt.minKeys() {
+			node.borrowFromLeft(i, &left.entries, &child.entries)
+			dt.pool.markDirty(node)
+			dt.pool.markDirty(left)
+			dt.pool.markDirty(child)
+			dt.pool.unpin(left)
+			return child, nil
+		}
+
+		// The left sibling is minimal, if there is no right sibling the child is
+		// merged into it.
+		if i == len(node.keys) {
+			dt.mergeChildren(node, i-1, left, child)
+			return left, nil
+		}
+		dt.pool.unpin(left)
+	}
+
+	// Borrow from the right sibling through the parent key.
+	right, err := dt.pool.fetch(node.children[i+1])
+	if err != nil {
+		dt.pool.unpin(child)
+		return nil, err
+	}
+	if len(right.keys) > dt.minKeys() {
+		node.borrowFromRight(i, &child.entries, &right.entries)
+		dt.pool.markDirty(node)
+		dt.pool.markDirty(right)
+		dt.pool.markDirty(child)
+		dt.pool.unpin(right)
+		return child, nil
+	}
+
+	dt.mergeChildren(node, i, child, right)
+	return child, nil
+}
+
+// mergeChildren merges the pinned right child at index i+1 of node and the key at
+// index i into the pinned left child at index i. The page of the right child is
+// freed.
+func (dt *DiskTree[K, V]) mergeChildren(node *diskNode[K, V], i int, left, right *diskNode[K, V]) {
+	node.mergeChildren(i, &left.entries, &right.entries)
+	dt.pool.markDirty(node)
+	dt.pool.markDirty(left)
+	dt.freeNode(right)
+}
+
+// Ascend calls the iterator for every key in the btree in ascending order until
+// the iterator returns false. The btree must not be modified while iterating.
+func (dt *DiskTree[K, V]) Ascend(iterator ItemIterator[K, V]) error {
+	return dt.ascendRange(nil, nil, iterator)
+}
+
+// AscendRange calls the iterator for every key in the btree within the range
+// [greaterOrEqual, lessThan) in ascending order until the iterator returns false.
+// The btree must not be modified while iterating.
+func (dt *DiskTree[K, V]) AscendRange(greaterOrEqual, lessThan K, iterator ItemIterator[K, V]) error {
+	return dt.ascendRange(&greaterOrEqual, &lessThan, iterator)
+}
+
+// ascendRange is AscendRange where a nil bound means the range is unbounded on
+// that side.
+func (dt *DiskTree[K, V]) ascendRange(greaterOrEqual, lessThan *K, iterator ItemIterator[K, V]) error {
+	if err := dt.usable(); err != nil {
+		return err
+	}
+	if dt.meta.root == 0 {
+		return nil
+	}
+
+	ascend := dt.ascend
+	if dt.mapped != nil {
+		ascend = dt.mappedAscend
+	}
+	_, err := ascend(dt.meta.root, greaterOrEqual, lessThan, iterator)
+	return err
+}
+
+func (dt *DiskTree[K, V]) ascend(id pageID, greaterOrEqual, lessThan *K, iterator ItemIterator[K, V]) (bool, error) {
+	node, err := dt.pool.fetch(id)
+	if err != nil {
+		return false, err
+	}
+	// The node is not modified while iterating, so it can be read after it is
+	// unpinned, which keeps the pool from filling up with the whole path.
+	dt.pool.unpin(node)
+
+	start := 0
+	if greaterOrEqual != nil {
+		start, _ = node.find(*greaterOrEqual, dt.compare)
+	}
+
+	for i := start; i <= len(node.keys); i++ {
+		if !node.isLeaf() {
+			if ok, err := dt.ascend(node.children[i], greaterOrEqual, lessThan, iterator); !ok || err != nil {
+				return false, err
+			}
+		}
+		if i == len(node.keys) {
+			break
+		}
+
+		key := node.keys[i]
+		if greaterOrEqual != nil && dt.compare(key.K, *greaterOrEqual) < 0 {
+			continue
+		}
+		if lessThan != nil && dt.compare(key.K, *lessThan) >= 0 {
+			return false, nil
+		}
+		if !iterator(key.K, key.V) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// newNode allocates a page for a new node, which is returned pinned. Pages in the
+// free list are reused before the file grows.
+func (dt *DiskTree[K, V]) newNode() (*diskNode[K, V], error) {
+	if dt.meta.freeHead != 0 {
+		node, err := dt.pool.fetch(dt.meta.freeHead)
+		if err != nil {
+			return nil, err
+		}
+		if !node.free {
+			dt.pool.unpin(node)
+			return nil, fmt.Errorf("%w: page %d in the free list is not free", ErrCorrupt, node.id)
+		}
+
+		dt.meta.freeHead = node.next
+		dt.meta.freeCount--
+		*node = diskNode[K, V]{
+			id:      node.id,
+			entries: entries[K, V, pageID]{keys: make([]Key[K, V], 0, dt.maxKeys())},
+		}
+		dt.pool.markDirty(node)
+		return node, nil
+	}
+
+	node := &diskNode[K, V]{
+		id:      pageID(dt.meta.pageCount),
+		entries: entries[K, V, pageID]{keys: make([]Key[K, V], 0, dt.maxKeys())},
+	}
+	if err := dt.pool.add(node); err != nil {
+		return nil, err
+	}
+
+	dt.meta.pageCount++
+	return node, nil
+}
+
+// freeNode adds the page of the pinned node, which is not referenced by the btree
+// anymore, to the free list. The node is unpinned.
+func (dt *DiskTree[K, V]) freeNode(node *diskNode[K, V]) {
+	*node = diskNode[K, V]{
+		id:   node.id,
+		free: true,
+		next: dt.meta.freeHead,
+	}
+	dt.meta.freeHead = node.id
+	dt.meta.freeCount++
+
+	dt.pool.markDirty(node)
+	dt.pool.unpin(node)
+}
+
+// usable returns the error for any operation if the btree is closed or failed.
+func (dt *DiskTree[K, V]) usable() error {
+	if dt.closed {
+		return ErrClosed
+	}
+	return dt.failed
+}
+
+// writable returns the error for a write if the btree can't be written.
+func (dt *DiskTree[K, V]) writable() error {
+	if err := dt.usable(); err != nil {
+		return err
+	}
+	if dt.mapped != nil {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// fail makes the btree unusable after the error of a commit, and returns it.
+func (dt *DiskTree[K, V]) fail(err error) error {
+	dt.failed = fmt.Errorf("%w: %w", ErrFailed, err)
+	return dt.failed
+}
+
+func (dt *DiskTree[K, V]) maxKeys() int {
+	return 2*dt.degree - 1
+}
+
+func (dt *DiskTree[K, V]) minKeys() int {
+	return dt.degree - 1
+}
+
+func (dt *DiskTree[K, V]) writeMeta() error {
+	dt.meta.encode(dt.scratch)
+	return dt.file.writePage(0, dt.scratch)
+}
+
+// readNode reads and decodes the node stored in the page.
+func (dt *DiskTree[K, V]) readNode(id pageID) (*diskNode[K, V], error) {
+	if id == 0 || uint64(id) >= dt.meta.pageCount {
+		return nil, fmt.Errorf("%w: page %d out of range", ErrCorrupt, id)
+	}
+
+	page := dt.scratch
+	if err := dt.file.readPage(id, page); err != nil {
+		return nil, err
+	}
+	return dt.decodeNode(id, page)
+}
+
+// writeNode encodes the node and writes it to its page.
+func (dt *DiskTree[K, V]) writeNode(node *diskNode[K, V]) error {
+	// The node is logged, but the WAL may not be in stable storage yet.
+	if err := dt.wal.sync(); err != nil {
+		return err
+	}
+
+	page := dt.scratch
+	if err := dt.encodeNode(node, page); err != nil {
+		return err
+	}
+	return dt.file.writePage(node.id, page)
+}
+
+func (dt *DiskTree[K, V]) encodeNode(node *diskNode[K, V], page []byte) error {
+	clear(page)
+
+	if node.free {
+		page[0] = pageFree
+		binary.LittleEndian.PutUint64(page[4:12], uint64(node.next))
+		putChecksum(page)
+		return nil
+	}
+
+	page[0] = pageLeaf
+	if !node.isLeaf() {
+		page[0] = pageInner
+	}
+	binary.LittleEndian.PutUint16(page[2:4], uint16(len(node.keys)))
+
+	offset := nodeHeaderSize
+	entrySize := dt.meta.keySize + dt.meta.valueSize
+	for _, key := range node.keys {
+		if err := dt.encodeEntry(page[offset:offset+entrySize], key); err != nil {
+			return err
+		}
+		offset += entrySize
+	}
+	for _, child := range node.children {
+		binary.LittleEndian.PutUint64(page[offset:], uint64(child))
+		offset += 8
+	}
+
+	putChecksum(page)
+	return nil
+}
+
+func (dt *DiskTree[K, V]) decodeNode(id pageID, page []byte) (*diskNode[K, V], error) {
+	if !validChecksum(page) {
+		return nil, fmt.Errorf("%w: page %d checksum mismatch", ErrCorrupt, id)
+	}
+
+	kind := page[0]
+	if kind == pageFree {
+		return &diskNode[K, V]{
+			id:   id,
+			free: true,
+			next: pageID(binary.LittleEndian.Uint64(page[4:12])),
+		}, nil
+	}
+
+	n := int(binary.LittleEndian.Uint16(page[2:4]))
+	if (kind != pageLeaf && kind != pageInner) || n > dt.maxKeys() {
+		return nil, fmt.Errorf("%w: page %d is not a valid node", ErrCorrupt, id)
+	}
+
+	node := &diskNode[K, V]{
+		id:      id,
+		entries: entries[K, V, pageID]{keys: make([]Key[K, V], n, dt.maxKeys())},
+	}
+
+	offset := nodeHeaderSize
+	entrySize := dt.meta.keySize + dt.meta.valueSize
+	for i := range node.keys {
+		key, err := dt.decodeEntry(page[offset : offset+entrySize])
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", id, err)
+		}
+		node.keys[i] = key
+		offset += entrySize
+	}
+
+	if kind == pageInner {
+		node.children = make([]pageID, n+1, dt.maxKeys()+1)
+		for i := range node.children {
+			node.children[i] = pageID(binary.LittleEndian.Uint64(page[offset:]))
+			offset += 8
+		}
+	}
+
+	return node, nil
+}
+
+func (dt *DiskTree[K, V]) encodeEntry(dst []byte, key Key[K, V]) error {
+	if err := dt.keyCodec.Encode(dst[:dt.meta.keySize], key.K); err != nil {
+		return err
+	}
+	return dt.valueCodec.Encode(dst[dt.meta.keySize:dt.meta.keySize+dt.meta.valueSize], key.V)
+}
+
+func (dt *DiskTree[K, V]) decodeEntry(src []byte) (Key[K, V], error) {
+	k, err := dt.keyCodec.Decode(src[:dt.meta.keySize])
+	if err != nil {
+		return Key[K, V]{}, err
+	}
+	v, err := dt.valueCodec.Decode(src[dt.meta.keySize : dt.meta.keySize+dt.meta.valueSize])
+	if err != nil {
+		return Key[K, V]{}, err
+	}
+	return Key[K, V]{K: k, V: v}, nil
+}
@@ -0,0 +1,390 @@
+package beetree
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// smallPages makes the disk tests build deep trees and evict pages all the time
+var smallPages = &Options{PageSize: 128, CacheSize: minCacheSize}
+
+// verifyDiskTree checks the btree invariants of a DiskTree and that every page is either a node or
+// in the free list. It returns the number of keys in the tree.
+func verifyDiskTree(t *testing.T, tree *DiskTree[int, int]) int {
+	t.Helper()
+
+	seen := map[pageID]bool{}
+	for id := tree.meta.freeHead; id != 0; {
+		node, err := tree.pool.fetch(id)
+		if err != nil {
+			t.Fatalf("Fetching free page %d: %v", id, err)
+		}
+		tree.pool.unpin(node)
+		if !node.free || seen[id] {
+			t.Fatalf("Page %d in the free list is not free", id)
+		}
+		seen[id] = true
+		id = node.next
+	}
+	if len(seen) != int(tree.meta.freeCount) {
+		t.Errorf("Free list has %d pages, expected %d", len(seen), tree.meta.freeCount)
+	}
+
+	if tree.meta.root == 0 {
+		if tree.meta.pageCount != 1+tree.meta.freeCount {
+			t.Errorf("Empty tree has %d pages, expected %d", tree.meta.pageCount, 1+tree.meta.freeCount)
+		}
+		return 0
+	}
+
+	defer func() {
+		if len(seen) != int(tree.meta.pageCount)-1 {
+			t.Errorf("Tree and free list have %d pages, the file has %d", len(seen), tree.meta.pageCount-1)
+		}
+	}()
+
+	leafDepth := -1
+	var walk func(id pageID, depth int, lo, hi *int, isRoot bool) int
+	walk = func(id pageID, depth int, lo, hi *int, isRoot bool) int {
+		node, err := tree.pool.fetch(id)
+		if err != nil {
+			t.Fatalf("Fetching page %d: %v", id, err)
+		}
+		tree.pool.unpin(node)
+
+		if node.free || seen[id] {
+			t.Fatalf("Page %d is free or referenced twice", id)
+		}
+		seen[id] = true
+
+		if !isRoot && len(node.keys) < tree.minKeys() {
+			t.Errorf("Non-root page %d has %d keys, minimum required: %d", id, len(node.keys), tree.minKeys())
+		}
+		if len(node.keys) > tree.maxKeys() {
+			t.Errorf("Page %d has %d keys, maximum allowed: %d", id, len(node.keys), tree.maxKeys())
+		}
+		for i, k := range node.keys {
+			if (i > 0 && node.keys[i-1].K >= k.K) || (lo != nil && k.K <= *lo) || (hi != nil && k.K >= *hi) {
+				t.Errorf("Key %d is out of order in page %d", k.K, id)
+			}
+		}
+
+		if node.isLeaf() {
+			if leafDepth == -1 {
+				leafDepth = depth
+			} else if leafDepth != depth {
+				t.Errorf("Leaf page %d at depth %d, expected %d", id, depth, leafDepth)
+			}
+			return len(node.keys)
+		}
+
+		count := len(node.keys)
+		for i, child := range node.children {
+			childLo, childHi := lo, hi
+			if i > 0 {
+				childLo = &node.keys[i-1].K
+			}
+			if i < len(node.keys) {
+				childHi = &node.keys[i].K
+			}
+			count += walk(child, depth+1, childLo, childHi, false)
+		}
+		return count
+	}
+
+	return walk(tree.meta.root, 0, nil, nil, true)
+}
+
+func openIntTree(t *testing.T, path string, opts *Options) *DiskTree[int, int] {
+	t.Helper()
+
+	tree, err := Open[int, int](path, IntCodec{}, IntCodec{}, opts)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	return tree
+}
+
+// TestDiskTreeSurvivesReopen tests random operations against a map, closing and reopening the file
+// several times
+func TestDiskTreeSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	r := rand.New(rand.NewSource(1))
+	expected := map[int]int{}
+
+	tree := openIntTree(t, path, smallPages)
+	if tree.Degree() != 2 {
+		t.Errorf("Expected degree 2 for 128 byte pages, got %d", tree.Degree())
+	}
+
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 1000; i++ {
+			k := r.Intn(800)
+			if r.Intn(3) == 0 {
+				v, found, err := tree.Delete(k)
+				ev, efound := expected[k]
+				if err != nil || found != efound || v != ev {
+					t.Fatalf("Delete(%d) = %d, %t, %v, expected %d, %t", k, v, found, err, ev, efound)
+				}
+				delete(expected, k)
+			} else {
+				old, replaced, err := tree.Put(k, i)
+				ev, efound := expected[k]
+				if err != nil || replaced != efound || old != ev {
+					t.Fatalf("Put(%d) = %d, %t, %v, expected %d, %t", k, old, replaced, err, ev, efound)
+				}
+				expected[k] = i
+			}
+		}
+
+		if err := tree.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		tree = openIntTree(t, path, &Options{CacheSize: minCacheSize})
+
+		if count := verifyDiskTree(t, tree); count != len(expected) || tree.Len() != len(expected) {
+			t.Fatalf("Expected %d keys, got %d (length %d)", len(expected), count, tree.Len())
+		}
+		for k, ev := range expected {
+			if v, found, err := tree.Get(k); err != nil || !found || v != ev {
+				t.Fatalf("Expected value %d for key %d, got %d (found %t, error %v)", ev, k, v, found, err)
+			}
+		}
+	}
+
+	prev, count := -1, 0
+	err := tree.AscendRange(100, 400, func(k, v int) bool {
+		if k <= prev || k < 100 || k >= 400 || v != expected[k] {
+			t.Errorf("Unexpected key %d with value %d after key %d", k, v, prev)
+		}
+		prev = k
+		count++
+		return true
+	})
+	if err != nil {
+		t.Fatalf("AscendRange failed: %v", err)
+	}
+	inRange := 0
+	for k := range expected {
+		if k >= 100 && k < 400 {
+			inRange++
+		}
+	}
+	if count != inRange {
+		t.Errorf("Expected to iterate over %d keys, got %d", inRange, count)
+	}
+
+	if err := tree.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, _, err := tree.Get(1); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed after Close, got %v", err)
+	}
+}
+
+// TestDiskTreeDegreeFromPageSize tests that the degree is the largest one whose nodes fit in a page
+func TestDiskTreeDegreeFromPageSize(t *testing.T) {
+	for _, pageSize := range []int{128, 512, 4096, 16384} {
+		tree := openIntTree(t, filepath.Join(t.TempDir(), "tree.db"), &Options{PageSize: pageSize})
+		degree := tree.Degree()
+		nodeSize := func(degree int) int {
+			return nodeHeaderSize + (2*degree-1)*16 + 2*degree*8 + 4
+		}
+		if nodeSize(degree) > pageSize || nodeSize(degree+1) <= pageSize {
+			t.Errorf("Degree %d does not fill pages of %d bytes", degree, pageSize)
+		}
+		tree.Close()
+	}
+
+	if _, err := Open[string, int](filepath.Join(t.TempDir(), "tree.db"), StringCodec{MaxLen: 100}, IntCodec{}, &Options{PageSize: 128}); err == nil {
+		t.Errorf("Expected an error for entries that don't fit twice in a page")
+	}
+}
+
+// TestDiskTreeRejectsPageSizesOutOfRange tests that a file can't be created with pages it could not
+// be reopened with, or whose nodes have more keys than a page can count
+func TestDiskTreeRejectsPageSizesOutOfRange(t *testing.T) {
+	for _, pageSize := range []int{minPageSize - 1, maxPageSize + 1} {
+		if _, err := Open[int, int](filepath.Join(t.TempDir(), "tree.db"), IntCodec{}, IntCodec{}, &Options{PageSize: pageSize}); err == nil {
+			t.Errorf("Expected an error for pages of %d bytes", pageSize)
+		}
+	}
+
+	// Entries of 4 bytes give nodes of more than 65535 keys in the largest pages.
+	path := filepath.Join(t.TempDir(), "tree.db")
+	if _, err := Open[string, string](path, StringCodec{}, StringCodec{}, &Options{PageSize: maxPageSize}); err == nil {
+		t.Errorf("Expected an error for nodes with too many keys")
+	}
+
+	tree := openIntTree(t, path, &Options{PageSize: maxPageSize})
+	tree.Put(1, 1)
+	tree.Close()
+	tree = openIntTree(t, path, nil)
+	if v, found, _ := tree.Get(1); !found || v != 1 {
+		t.Errorf("Expected to find key 1 after reopening a file with the largest pages")
+	}
+	tree.Close()
+}
+
+// TestDiskTreeRejectsMismatchedFile tests that a file can only be opened with the codecs and the
+// page size it was created with
+func TestDiskTreeRejectsMismatchedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree := openIntTree(t, path, smallPages)
+	tree.Put(1, 1)
+	tree.Close()
+
+	if _, err := Open[string, int](path, StringCodec{MaxLen: 16}, IntCodec{}, nil); err == nil {
+		t.Errorf("Expected an error opening the file with a different key codec")
+	}
+	if _, err := Open[int, int](path, IntCodec{}, IntCodec{}, &Options{PageSize: 4096}); err == nil {
+		t.Errorf("Expected an error opening the file with a different page size")
+	}
+
+	notATree := filepath.Join(t.TempDir(), "other")
+	os.WriteFile(notATree, []byte(strings.Repeat("x", 4096)), 0o644)
+	if _, err := Open[int, int](notATree, IntCodec{}, IntCodec{}, nil); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt opening a file that is not a tree, got %v", err)
+	}
+}
+
+// TestDiskTreeStringKeys tests a DiskTree with string keys and a value that is too long
+func TestDiskTreeStringKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree, err := Open[string, string](path, StringCodec{MaxLen: 8}, StringCodec{MaxLen: 8}, &Options{PageSize: 256})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	words := strings.Fields("the quick brown fox jumps over the lazy dog")
+	for _, w := range words {
+		if _, _, err := tree.Put(w, strings.ToUpper(w)); err != nil {
+			t.Fatalf("Put(%q) failed: %v", w, err)
+		}
+	}
+	if _, _, err := tree.Put("toolongkey", "v"); err == nil {
+		t.Errorf("Expected an error for a key longer than the codec allows")
+	}
+	tree.Close()
+
+	tree, err = Open[string, string](path, StringCodec{MaxLen: 8}, StringCodec{MaxLen: 8}, nil)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer tree.Close()
+
+	if tree.Len() != 8 {
+		t.Errorf("Expected 8 keys, got %d", tree.Len())
+	}
+	var keys []string
+	tree.Ascend(func(k, v string) bool {
+		keys = append(keys, k)
+		return true
+	})
+	if got := strings.Join(keys, " "); got != "brown dog fox jumps lazy over quick the" {
+		t.Errorf("Unexpected keys %q", got)
+	}
+	if v, found, _ := tree.Get("fox"); !found || v != "FOX" {
+		t.Errorf("Expected value FOX for key fox, got %q (found %t)", v, found)
+	}
+}
+
+// TestDiskTreeReusesFreePages tests that the pages of merged nodes are reused by new nodes
+func TestDiskTreeReusesFreePages(t *testing.T) {
+	tree := openIntTree(t, filepath.Join(t.TempDir(), "tree.db"), smallPages)
+	defer tree.Close()
+
+	for i := 0; i < 1000; i++ {
+		tree.Put(i, i)
+	}
+	grown := tree.Stats()
+	if grown.FreePages != 0 || grown.LivePages != grown.Pages-1 {
+		t.Errorf("Expected no free pages after inserting, got %+v", grown)
+	}
+
+	for i := 0; i < 1000; i += 2 {
+		tree.Delete(i)
+	}
+	shrunk := tree.Stats()
+	if shrunk.FreePages == 0 || shrunk.Pages != grown.Pages || shrunk.LivePages+shrunk.FreePages != shrunk.Pages-1 {
+		t.Errorf("Expected the pages of merged nodes to be free, got %+v", shrunk)
+	}
+	verifyDiskTree(t, tree)
+
+	for i := 0; i < 1000; i += 2 {
+		tree.Put(i, i)
+	}
+	regrown := tree.Stats()
+	if regrown.Pages > grown.Pages+shrunk.FreePages/2 || regrown.FreePages >= shrunk.FreePages {
+		t.Errorf("Expected the free pages to be reused, got %+v after %+v", regrown, shrunk)
+	}
+	if count := verifyDiskTree(t, tree); count != 1000 {
+		t.Errorf("Expected 1000 keys, got %d", count)
+	}
+}
+
+// TestDiskTreeCompact tests that Compact shrinks the file to its live pages and keeps the tree usable
+func TestDiskTreeCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree := openIntTree(t, path, smallPages)
+
+	for i := 0; i < 2000; i++ {
+		tree.Put(i, i)
+	}
+	for i := 0; i < 2000; i++ {
+		if i%10 != 0 {
+			tree.Delete(i)
+		}
+	}
+	before := tree.Stats()
+	if before.FreePages == 0 {
+		t.Fatalf("Expected free pages before compacting, got %+v", before)
+	}
+
+	if err := tree.Compact(); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	after := tree.Stats()
+	if after.FreePages != 0 || after.LivePages != before.LivePages || after.Pages != after.LivePages+1 {
+		t.Errorf("Expected only live pages after compacting, got %+v (before %+v)", after, before)
+	}
+	if info, _ := os.Stat(path); info.Size() != int64(after.Pages*after.PageSize) {
+		t.Errorf("Expected a file of %d bytes, got %d", after.Pages*after.PageSize, info.Size())
+	}
+	if count := verifyDiskTree(t, tree); count != 200 {
+		t.Errorf("Expected 200 keys, got %d", count)
+	}
+
+	// The tree keeps working on the compacted file and survives reopening it.
+	tree.Put(1, 1)
+	tree.Delete(10)
+	if err := tree.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	tree = openIntTree(t, path, nil)
+	defer tree.Close()
+	if count := verifyDiskTree(t, tree); count != 200 {
+		t.Errorf("Expected 200 keys, got %d", count)
+	}
+	if found, _ := tree.Has(1); !found {
+		t.Errorf("Expected key 1 to be found")
+	}
+	if found, _ := tree.Has(10); found {
+		t.Errorf("Expected key 10 to be deleted")
+	}
+
+	// Compacting an empty tree leaves only the meta page.
+	for i := 0; i < 2000; i++ {
+		tree.Delete(i)
+	}
+	if err := tree.Compact(); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if stats := tree.Stats(); stats.Pages != 1 || stats.Keys != 0 {
+		t.Errorf("Expected an empty file, got %+v", stats)
+	}
+}
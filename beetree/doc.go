@@ -0,0 +1,23 @@
+// Package beetree implements B-trees of arbitrary degree, in memory and on disk.
+//
+// The package has four btrees, for different uses:
+//
+//   - BeeTree is the in-memory btree. Its nodes are copied on write, so clones
+//     share them, and they keep the size of their subtree for Rank and Select.
+//     Writes go down to the key and fix full or underflowing nodes on the way
+//     back up.
+//   - LatchTree is safe for concurrent use with a latch in every node, and
+//     DiskTree stores its nodes in the pages of a file. Both write in a single
+//     pass from the root, splitting full nodes and filling minimal nodes on the
+//     way down, since a latched or pinned parent node can't be revisited. They
+//     share the moves of keys between nodes, only the way nodes are reached
+//     differs.
+//   - BLinkTree is safe for concurrent use with readers that never block. Its
+//     nodes are immutable versions with high keys and right links, keys and
+//     values are only stored in the leaf nodes and deletes never merge nodes.
+//
+// The node layouts follow from those uses: a node shared by clones can't carry
+// a latch, a node that is read without latches can't be modified in place, and
+// a node on disk holds page ids instead of pointers. That is why the btrees
+// keep their own node types instead of wrapping BeeTree nodes.
+package beetree
@@ -0,0 +1,148 @@
+package beetree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// ErrInvalidEncoding is returned when decoding data that is not an encoded btree
+// or that was damaged.
+var ErrInvalidEncoding = errors.New("beetree: invalid encoded btree")
+
+// The encoded btree is a header, the keys in ascending order and a checksum:
+//
+//	[0:8]   magic
+//	[8:12]  encoding version
+//	[12:16] degree
+//	[16:24] number of keys
+//	[24:]   keys and values encoded with encoding/gob
+//	last 4  crc32 of all the previous bytes
+const (
+	encodingMagic      = "BEEDUMP\x00"
+	encodingVersion    = 1
+	encodingHeaderSize = 24
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler. The keys and values are
+// encoded with encoding/gob, so their types must be supported by it.
+func (bt *BeeTree[K, V]) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := bt.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTo implements io.WriterTo. It writes the btree in the format of
+// MarshalBinary.
+func (bt *BeeTree[K, V]) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	crc := crc32.NewIEEE()
+	mw := io.MultiWriter(cw, crc)
+
+	header := make([]byte, encodingHeaderSize)
+	copy(header[0:8], encodingMagic)
+	binary.LittleEndian.PutUint32(header[8:12], encodingVersion)
+	binary.LittleEndian.PutUint32(header[12:16], uint32(bt.Degree))
+	binary.LittleEndian.PutUint64(header[16:24], uint64(bt.length))
+	if _, err := mw.Write(header); err != nil {
+		return cw.n, err
+	}
+
+	enc := gob.NewEncoder(mw)
+	var err error
+	bt.Ascend(func(key K, value V) bool {
+		err = enc.Encode(Key[K, V]{K: key, V: value})
+		return err == nil
+	})
+	if err != nil {
+		return cw.n, fmt.Errorf("beetree: encoding key: %w", err)
+	}
+
+	_, err = cw.Write(binary.LittleEndian.AppendUint32(nil, crc.Sum32()))
+	return cw.n, err
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It replaces the
+// content and the degree of the btree with the ones of the encoded btree, which
+// must be created with NewBeetree or NewBeetreeFunc.
+//
+// The btree is built bottom-up from the sorted keys in O(n), with nodes as full
+// as possible. The keys must be in ascending order for the compare function of
+// the btree.
+func (bt *BeeTree[K, V]) UnmarshalBinary(data []byte) error {
+	if bt.compare == nil {
+		return errors.New("beetree: UnmarshalBinary needs a btree created by NewBeetree or NewBeetreeFunc")
+	}
+
+	if len(data) < encodingHeaderSize+4 || string(data[0:8]) != encodingMagic {
+		return fmt.Errorf("%w: bad header", ErrInvalidEncoding)
+	}
+	if version := binary.LittleEndian.Uint32(data[8:12]); version != encodingVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidEncoding, version)
+	}
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(body):]) {
+		return fmt.Errorf("%w: checksum mismatch", ErrInvalidEncoding)
+	}
+
+	degree := int(binary.LittleEndian.Uint32(data[12:16]))
+	if degree < 2 {
+		return fmt.Errorf("%w: degree %d", ErrInvalidEncoding, degree)
+	}
+	count := binary.LittleEndian.Uint64(data[16:24])
+	// Every key takes at least one byte, which bounds the allocation.
+	if count > uint64(len(body)-encodingHeaderSize) {
+		return fmt.Errorf("%w: %d keys in %d bytes", ErrInvalidEncoding, count, len(body))
+	}
+
+	r := bytes.NewReader(body[encodingHeaderSize:])
+	dec := gob.NewDecoder(r)
+	keys := make([]Key[K, V], 0, count)
+	for i := uint64(0); i < count; i++ {
+		var key Key[K, V]
+		if err := dec.Decode(&key); err != nil {
+			return fmt.Errorf("%w: decoding key %d: %v", ErrInvalidEncoding, i, err)
+		}
+		if i > 0 && bt.compare(keys[i-1].K, key.K) >= 0 {
+			return fmt.Errorf("%w: key %d is not greater than the previous key", ErrInvalidEncoding, i)
+		}
+		keys = append(keys, key)
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("%w: %d bytes after the last key", ErrInvalidEncoding, r.Len())
+	}
+
+	bt.Degree = degree
+	bt.Root = bt.build(keys, 2*degree-1)
+	bt.length = len(keys)
+	bt.version++
+	return nil
+}
+
+// ReadFrom implements io.ReaderFrom. It reads r until EOF and decodes the data
+// like UnmarshalBinary.
+func (bt *BeeTree[K, V]) ReadFrom(r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return int64(len(data)), err
+	}
+	return int64(len(data)), bt.UnmarshalBinary(data)
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,126 @@
+package beetree
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestMarshalBinary tests that unmarshaling a marshaled tree gives back the same keys, values and
+// degree
+func TestMarshalBinary(t *testing.T) {
+	for _, n := range []int{0, 1, 10, 1000} {
+		tree := NewBeetree[int, int](5)
+		for _, key := range perm(n) {
+			tree.Put(key.K, -key.K)
+		}
+
+		data, err := tree.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary failed: %v", err)
+		}
+
+		loaded := NewBeetree[int, int](2)
+		if err := loaded.UnmarshalBinary(data); err != nil {
+			t.Fatalf("UnmarshalBinary failed: %v", err)
+		}
+
+		if loaded.Degree != 5 {
+			t.Errorf("Expected degree 5, got %d", loaded.Degree)
+		}
+		if loaded.Len() != n {
+			t.Errorf("Expected %d keys, got %d", n, loaded.Len())
+		}
+		if !reflect.DeepEqual(collectPairs(loaded), collectPairs(tree)) {
+			t.Errorf("Loaded tree has different keys or values")
+		}
+		verifyBTreeProperties(t, loaded, loaded.Root, loaded.Degree, true)
+		verifyLeafDepth(t, loaded)
+
+		// The loaded tree can be modified like any other.
+		loaded.Put(n, -n)
+		loaded.Delete(n - 1)
+		if loaded.Len() != max(n, 1) || !loaded.Has(n) || loaded.Has(n-1) {
+			t.Errorf("Unexpected keys after Put and Delete on the loaded tree")
+		}
+	}
+}
+
+// TestWriteToReadFrom tests streaming a tree with string keys
+func TestWriteToReadFrom(t *testing.T) {
+	tree := NewBeetree[string, []byte](3)
+	for _, s := range []string{"pear", "apple", "fig", "", "banana"} {
+		tree.Put(s, []byte(strings.ToUpper(s)))
+	}
+
+	var buf bytes.Buffer
+	written, err := tree.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if written != int64(buf.Len()) {
+		t.Errorf("WriteTo reported %d bytes, wrote %d", written, buf.Len())
+	}
+
+	loaded := NewBeetree[string, []byte](3)
+	loaded.Put("stale", nil)
+	read, err := loaded.ReadFrom(&buf)
+	if err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	if read != written {
+		t.Errorf("ReadFrom reported %d bytes, expected %d", read, written)
+	}
+
+	var keys []string
+	for k, v := range loaded.All() {
+		if string(v) != strings.ToUpper(k) {
+			t.Errorf("Unexpected value %q for key %q", v, k)
+		}
+		keys = append(keys, k)
+	}
+	if !reflect.DeepEqual(keys, []string{"", "apple", "banana", "fig", "pear"}) {
+		t.Errorf("Unexpected keys %q", keys)
+	}
+}
+
+// TestUnmarshalBinaryRejectsInvalidData tests that damaged data is rejected and leaves the tree
+// unchanged
+func TestUnmarshalBinaryRejectsInvalidData(t *testing.T) {
+	tree := NewBeetree[int, int](3)
+	for i := 0; i < 100; i++ {
+		tree.Put(i, i)
+	}
+	data, err := tree.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+
+	flipped := bytes.Clone(data)
+	flipped[len(flipped)/2] ^= 1
+
+	cases := map[string][]byte{
+		"empty":     nil,
+		"truncated": data[:len(data)-1],
+		"flipped":   flipped,
+		"magic":     append([]byte("NOTATREE"), data[8:]...),
+	}
+	for name, data := range cases {
+		loaded := NewBeetree[int, int](3)
+		loaded.Put(-1, -1)
+		if err := loaded.UnmarshalBinary(data); !errors.Is(err, ErrInvalidEncoding) {
+			t.Errorf("%s: expected ErrInvalidEncoding, got %v", name, err)
+		}
+		if loaded.Len() != 1 || !loaded.Has(-1) {
+			t.Errorf("%s: tree was modified by a failed UnmarshalBinary", name)
+		}
+	}
+
+	// The keys are sorted for a different order than the one of the tree.
+	reversed := NewBeetreeFunc[int, int](3, func(a, b int) int { return b - a })
+	if err := reversed.UnmarshalBinary(data); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("Expected ErrInvalidEncoding loading keys in the wrong order, got %v", err)
+	}
+}
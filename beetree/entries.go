@@ -0,0 +1,116 @@
+package beetree
+
+// entries are the keys and the children of a node of a LatchTree or a DiskTree.
+// The children are of type C: the child nodes of a LatchTree and the page ids of
+// a DiskTree. The methods moving keys between nodes don't latch, pin or mark
+// nodes dirty, their callers do.
+type entries[K, V, C any] struct {
+	keys     []Key[K, V]
+	children []C
+}
+
+func (n *entries[K, V, C]) isLeaf() bool {
+	return len(n.children) == 0
+}
+
+// find returns the index of the key if found in the node, otherwise it returns
+// the index of the child where the key could be stored.
+func (n *entries[K, V, C]) find(key K, compare func(a, b K) int) (int, bool) {
+	lo, hi := 0, len(n.keys)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		switch c := compare(n.keys[mid].K, key); {
+		case c == 0:
+			return mid, true
+		case c < 0:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+
+	return lo, false
+}
+
+func (n *entries[K, V, C]) insertKeyAt(i int, key Key[K, V]) {
+	n.keys = append(n.keys, Key[K, V]{})
+	copy(n.keys[i+1:], n.keys[i:])
+	n.keys[i] = key
+}
+
+func (n *entries[K, V, C]) removeKeyAt(i int) Key[K, V] {
+	key := n.keys[i]
+	copy(n.keys[i:], n.keys[i+1:])
+	n.keys[len(n.keys)-1] = Key[K, V]{}
+	n.keys = n.keys[:len(n.keys)-1]
+	return key
+}
+
+func (n *entries[K, V, C]) insertChildAt(i int, child C) {
+	var zero C
+	n.children = append(n.children, zero)
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = child
+}
+
+func (n *entries[K, V, C]) removeChildAt(i int) C {
+	child := n.children[i]
+	copy(n.children[i:], n.children[i+1:])
+	var zero C
+	n.children[len(n.children)-1] = zero
+	n.children = n.children[:len(n.children)-1]
+	return child
+}
+
+// splitChild splits the full child at index i of n, which has 2t-1 keys for the
+// given degree t. The middle key of the child moves to n and the keys bigger than
+// the middle key move to the empty sibling, which becomes the child at index i+1
+// through id.
+func (n *entries[K, V, C]) splitChild(i int, child, sibling *entries[K, V, C], id C, degree int) {
+	middleIndex := degree - 1
+	sibling.keys = append(sibling.keys, child.keys[middleIndex+1:]...)
+	if !child.isLeaf() {
+		sibling.children = append(sibling.children, child.children[middleIndex+1:]...)
+		clear(child.children[middleIndex+1:])
+		child.children = child.children[:middleIndex+1]
+	}
+	middleKey := child.keys[middleIndex]
+	clear(child.keys[middleIndex:])
+	child.keys = child.keys[:middleIndex]
+
+	n.insertKeyAt(i, middleKey)
+	n.insertChildAt(i+1, id)
+}
+
+// borrowFromLeft moves the largest key of left, the child at index i-1 of n, up
+// to n, and the key at index i-1 of n down to child, the child at index i. The
+// last child of left moves with them.
+func (n *entries[K, V, C]) borrowFromLeft(i int, left, child *entries[K, V, C]) {
+	child.insertKeyAt(0, n.keys[i-1])
+	n.keys[i-1] = left.removeKeyAt(len(left.keys) - 1)
+	if !left.isLeaf() {
+		child.insertChildAt(0, left.removeChildAt(len(left.children)-1))
+	}
+}
+
+// borrowFromRight moves the smallest key of right, the child at index i+1 of n,
+// up to n, and the key at index i of n down to child, the child at index i. The
+// first child of right moves with them.
+func (n *entries[K, V, C]) borrowFromRight(i int, child, right *entries[K, V, C]) {
+	child.keys = append(child.keys, n.keys[i])
+	n.keys[i] = right.removeKeyAt(0)
+	if !right.isLeaf() {
+		child.children = append(child.children, right.removeChildAt(0))
+	}
+}
+
+// mergeChildren merges right, the child at index i+1 of n, and the key at index i
+// into left, the child at index i. right is not referenced by n anymore.
+func (n *entries[K, V, C]) mergeChildren(i int, left, right *entries[K, V, C]) {
+	left.keys = append(left.keys, n.keys[i])
+	left.keys = append(left.keys, right.keys...)
+	left.children = append(left.children, right.children...)
+
+	n.removeKeyAt(i)
+	n.removeChildAt(i + 1)
+}
@@ -0,0 +1,147 @@
+package beetree
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrInvalidLayout is returned when a Layout does not describe a valid btree.
+var ErrInvalidLayout = errors.New("beetree: invalid layout")
+
+// Layout is the structure of a btree: its degree and all its nodes. A Layout can
+// be encoded to JSON and back to build a btree with the exact same nodes, which
+// is useful to test specific shapes of the btree.
+type Layout[K, V any] struct {
+	Degree int               `json:"degree"`
+	Root   *LayoutNode[K, V] `json:"root"`
+}
+
+// LayoutNode is a node of a Layout.
+type LayoutNode[K, V any] struct {
+	// Level is the depth of the node, 0 for the root.
+	Level    int                 `json:"level"`
+	Keys     []Key[K, V]         `json:"keys"`
+	Children []*LayoutNode[K, V] `json:"children,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. The btree is encoded as the list of its
+// keys and values in ascending order:
+//
+//	[{"key":1,"value":"a"},{"key":2,"value":"b"}]
+//
+// Use Layout to encode the nodes of the btree.
+func (bt *BeeTree[K, V]) MarshalJSON() ([]byte, error) {
+	keys := make([]Key[K, V], 0, bt.length)
+	bt.Ascend(func(key K, value V) bool {
+		keys = append(keys, Key[K, V]{K: key, V: value})
+		return true
+	})
+	return json.Marshal(keys)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It replaces the content of the
+// btree, which must be created with NewBeetree or NewBeetreeFunc, with the keys
+// and values of a list encoded by MarshalJSON. The list can be in any order but
+// must not have duplicated keys.
+func (bt *BeeTree[K, V]) UnmarshalJSON(data []byte) error {
+	if bt.compare == nil {
+		return errors.New("beetree: UnmarshalJSON needs a btree created by NewBeetree or NewBeetreeFunc")
+	}
+
+	var keys []Key[K, V]
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+
+	slices.SortFunc(keys, func(a, b Key[K, V]) int { return bt.compare(a.K, b.K) })
+	for i := 1; i < len(keys); i++ {
+		if bt.compare(keys[i-1].K, keys[i].K) == 0 {
+			return fmt.Errorf("beetree: duplicated key %v", keys[i].K)
+		}
+	}
+
+	bt.Root = bt.build(keys, 2*bt.Degree-1)
+	bt.length = len(keys)
+	bt.version++
+	return nil
+}
+
+// Layout returns the structure of the btree. The keys are copied, so the Layout
+// does not change with the btree.
+func (bt *BeeTree[K, V]) Layout() *Layout[K, V] {
+	var layout func(node *Node[K, V], level int) *LayoutNode[K, V]
+	layout = func(node *Node[K, V], level int) *LayoutNode[K, V] {
+		out := &LayoutNode[K, V]{Level: level, Keys: slices.Clone(node.Keys)}
+		for _, child := range node.Children {
+			out.Children = append(out.Children, layout(child, level+1))
+		}
+		return out
+	}
+
+	out := &Layout[K, V]{Degree: bt.Degree}
+	if bt.Root != nil && len(bt.Root.Keys) > 0 {
+		out.Root = layout(bt.Root, 0)
+	}
+	return out
+}
+
+// NewBeetreeFromLayout creates a BeeTree for keys with a natural ordering with
+// the exact nodes of the layout. It returns ErrInvalidLayout if the layout is
+// not a valid btree.
+func NewBeetreeFromLayout[K cmp.Ordered, V any](layout *Layout[K, V]) (*BeeTree[K, V], error) {
+	return NewBeetreeFromLayoutFunc(layout, cmp.Compare[K])
+}
+
+// NewBeetreeFromLayoutFunc creates a BeeTree whose keys are ordered by compare
+// with the exact nodes of the layout. It returns ErrInvalidLayout if the layout
+// is not a valid btree.
+func NewBeetreeFromLayoutFunc[K, V any](layout *Layout[K, V], compare func(a, b K) int) (*BeeTree[K, V], error) {
+	if layout.Degree < 2 {
+		return nil, fmt.Errorf("%w: degree %d is less than 2", ErrInvalidLayout, layout.Degree)
+	}
+
+	bt := NewBeetreeFunc[K, V](layout.Degree, compare)
+	if layout.Root == nil {
+		return bt, nil
+	}
+
+	root, err := loadLayoutNode(bt, layout.Root, nil)
+	if err != nil {
+		return nil, err
+	}
+	bt.Root = root
+	bt.length = root.size
+	if err := bt.Verify(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidLayout, err)
+	}
+	return bt, nil
+}
+
+// loadLayoutNode creates the subtree of the layout node found at path.
+func loadLayoutNode[K, V any](bt *BeeTree[K, V], ln *LayoutNode[K, V], path []int) (*Node[K, V], error) {
+	switch {
+	case ln == nil:
+		return nil, fmt.Errorf("%w: node %s is missing", ErrInvalidLayout, formatPath(path))
+	case ln.Level != len(path):
+		return nil, fmt.Errorf("%w: node %s has level %d, expected %d", ErrInvalidLayout, formatPath(path), ln.Level, len(path))
+	case len(path) == 0 && len(ln.Keys) == 0:
+		// An empty btree has no root in its layout.
+		return nil, fmt.Errorf("%w: root has no keys", ErrInvalidLayout)
+	}
+
+	node := bt.newNode()
+	node.Keys = append(node.Keys, ln.Keys...)
+	for i, lc := range ln.Children {
+		child, err := loadLayoutNode(bt, lc, append(path[:len(path):len(path)], i))
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, child)
+	}
+	node.updateSize()
+
+	return node, nil
+}
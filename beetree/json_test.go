@@ -0,0 +1,151 @@
+package beetree
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// mustLayout decodes a JSON layout
+func mustLayout(t *testing.T, data string) *Layout[int, int] {
+	t.Helper()
+
+	var layout Layout[int, int]
+	if err := json.Unmarshal([]byte(data), &layout); err != nil {
+		t.Fatalf("Decoding layout: %v", err)
+	}
+	return &layout
+}
+
+// TestMarshalJSON tests encoding a tree as the list of its keys and decoding it back
+func TestMarshalJSON(t *testing.T) {
+	tree := NewBeetree[int, string](2)
+	tree.Put(2, "b")
+	tree.Put(1, "a")
+	tree.Put(3, "c")
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if expected := `[{"key":1,"value":"a"},{"key":2,"value":"b"},{"key":3,"value":"c"}]`; string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	// The list does not need to be sorted.
+	loaded := NewBeetree[int, string](2)
+	if err := json.Unmarshal([]byte(`[{"key":3,"value":"c"},{"key":1,"value":"a"},{"key":2,"value":"b"}]`), loaded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	var keys []int
+	for k, v := range loaded.All() {
+		if v != string(rune('a'+k-1)) {
+			t.Errorf("Unexpected value %q for key %d", v, k)
+		}
+		keys = append(keys, k)
+	}
+	if !reflect.DeepEqual(keys, []int{1, 2, 3}) || loaded.Len() != 3 {
+		t.Errorf("Unexpected keys %v", keys)
+	}
+
+	if err := json.Unmarshal([]byte(`[{"key":1},{"key":1}]`), loaded); err == nil {
+		t.Errorf("Expected an error decoding duplicated keys")
+	}
+}
+
+// TestLayoutRoundTrip tests that a tree created from the layout of another tree has the same nodes
+func TestLayoutRoundTrip(t *testing.T) {
+	for _, n := range []int{0, 1, 50, 500} {
+		tree := buildTreeWithPerm(3, n)
+
+		data, err := json.Marshal(tree.Layout())
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		var layout Layout[int, int]
+		if err := json.Unmarshal(data, &layout); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+
+		loaded, err := NewBeetreeFromLayout(&layout)
+		if err != nil {
+			t.Fatalf("NewBeetreeFromLayout failed: %v", err)
+		}
+		if !reflect.DeepEqual(loaded.Layout(), tree.Layout()) {
+			t.Errorf("Loaded tree with %d keys has different nodes", n)
+		}
+		if loaded.Len() != n {
+			t.Errorf("Expected %d keys, got %d", n, loaded.Len())
+		}
+		verifyBTreeProperties(t, loaded, loaded.Root, loaded.Degree, true)
+	}
+}
+
+// TestLayoutPinsTreeShape tests an insert that splits a full leaf whose parent, the root, is full
+// too, starting from a layout instead of a sequence of inserts
+func TestLayoutPinsTreeShape(t *testing.T) {
+	tree, err := NewBeetreeFromLayout(mustLayout(t, `{"degree": 2, "root": {"level": 0, "keys": [{"key": 20}, {"key": 40}, {"key": 60}], "children": [
+		{"level": 1, "keys": [{"key": 5}, {"key": 10}, {"key": 15}]},
+		{"level": 1, "keys": [{"key": 25}]},
+		{"level": 1, "keys": [{"key": 45}]},
+		{"level": 1, "keys": [{"key": 65}]}
+	]}}`))
+	if err != nil {
+		t.Fatalf("NewBeetreeFromLayout failed: %v", err)
+	}
+
+	tree.Put(12, 0)
+
+	expected := mustLayout(t, `{"degree": 2, "root": {"level": 0, "keys": [{"key": 40}], "children": [
+		{"level": 1, "keys": [{"key": 10}, {"key": 20}], "children": [
+			{"level": 2, "keys": [{"key": 5}]},
+			{"level": 2, "keys": [{"key": 12}, {"key": 15}]},
+			{"level": 2, "keys": [{"key": 25}]}
+		]},
+		{"level": 1, "keys": [{"key": 60}], "children": [
+			{"level": 2, "keys": [{"key": 45}]},
+			{"level": 2, "keys": [{"key": 65}]}
+		]}
+	]}}`)
+	if layout := tree.Layout(); !reflect.DeepEqual(layout, expected) {
+		got, _ := json.Marshal(layout)
+		t.Errorf("Unexpected layout after the insert: %s", got)
+	}
+	verifyBTreeProperties(t, tree, tree.Root, tree.Degree, true)
+}
+
+// TestLayoutRejectsInvalidTrees tests that layouts breaking the btree rules are rejected
+func TestLayoutRejectsInvalidTrees(t *testing.T) {
+	cases := map[string]string{
+		"degree":        `{"degree": 1, "root": {"level": 0, "keys": [{"key": 1}]}}`,
+		"empty root":    `{"degree": 2, "root": {"level": 0, "keys": []}}`,
+		"too many keys": `{"degree": 2, "root": {"level": 0, "keys": [{"key": 1}, {"key": 2}, {"key": 3}, {"key": 4}]}}`,
+		"unsorted":      `{"degree": 2, "root": {"level": 0, "keys": [{"key": 2}, {"key": 1}]}}`,
+		"level":         `{"degree": 2, "root": {"level": 1, "keys": [{"key": 1}]}}`,
+		"children": `{"degree": 2, "root": {"level": 0, "keys": [{"key": 5}], "children": [
+			{"level": 1, "keys": [{"key": 1}]}
+		]}}`,
+		"too few keys": `{"degree": 3, "root": {"level": 0, "keys": [{"key": 5}], "children": [
+			{"level": 1, "keys": [{"key": 1}, {"key": 2}]},
+			{"level": 1, "keys": [{"key": 6}]}
+		]}}`,
+		"out of range": `{"degree": 2, "root": {"level": 0, "keys": [{"key": 5}], "children": [
+			{"level": 1, "keys": [{"key": 1}]},
+			{"level": 1, "keys": [{"key": 4}]}
+		]}}`,
+		"leaf depth": `{"degree": 2, "root": {"level": 0, "keys": [{"key": 5}], "children": [
+			{"level": 1, "keys": [{"key": 1}]},
+			{"level": 1, "keys": [{"key": 7}], "children": [
+				{"level": 2, "keys": [{"key": 6}]},
+				{"level": 2, "keys": [{"key": 8}]}
+			]}
+		]}}`,
+	}
+
+	for name, data := range cases {
+		if _, err := NewBeetreeFromLayout(mustLayout(t, data)); !errors.Is(err, ErrInvalidLayout) {
+			t.Errorf("%s: expected ErrInvalidLayout, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package beetree
+
+import (
+	"cmp"
+	"sync"
+	"sync/atomic"
+)
+
+// LatchTree is a btree that is safe for concurrent use by multiple goroutines
+// where every node carries its own latch (a read/write lock).
+//
+// Operations use latch crabbing (lock coupling): the latch of a child node is
+// acquired before the latch of its parent node is released. Writes follow the
+// single pass algorithms from CLRS, full nodes are split and minimal nodes are
+// filled on the way down, so a write never has to go back up to a parent node.
+// That allows releasing the latch of every parent node as soon as the write
+// moves to its child, letting writes to different subtrees run in parallel.
+//
+// Latches are always acquired from the root to the leaf nodes, and from a parent
+// node to its children, which prevents deadlocks.
+type LatchTree[K, V any] struct {
+	// mu protects the root pointer. It is held while the root node may be split or
+	// replaced.
+	mu   sync.RWMutex
+	root *latchNode[K, V]
+
+	degree  int
+	length  atomic.Int64
+	compare func(a, b K) int
+}
+
+type latchNode[K, V any] struct {
+	mu sync.RWMutex
+	entries[K, V, *latchNode[K, V]]
+}
+
+// NewLatchTree creates a LatchTree of the given degree for keys with a natural
+// ordering.
+func NewLatchTree[K cmp.Ordered, V any](degree int) *LatchTree[K, V] {
+	return NewLatchTreeFunc[K, V](degree, cmp.Compare[K])
+}
+
+// NewLatchTreeFunc creates a LatchTree of the given degree whose keys are
+// ordered by compare.
+func NewLatchTreeFunc[K, V any](degree int, compare func(a, b K) int) *LatchTree[K, V] {
+	if degree < 2 {
+		panic("beetree: degree must be at least 2")
+	}
+
+	return &LatchTree[K, V]{
+		degree:  degree,
+		compare: compare,
+	}
+}
+
+// Len returns the number of keys stored in the btree.
+func (lt *LatchTree[K, V]) Len() int {
+	return int(lt.length.Load())
+}
+
+// Get returns the value stored for the key. The second value reports whether
+// the key was found.
+func (lt *LatchTree[K, V]) Get(key K) (V, bool) {
+	lt.mu.RLock()
+	node := lt.root
+	if node == nil {
+		lt.mu.RUnlock()
+		var zero V
+		return zero, false
+	}
+	node.mu.RLock()
+	lt.mu.RUnlock()
+
+	for {
+		i, found := node.find(key, lt.compare)
+		if found {
+			value := node.keys[i].V
+			node.mu.RUnlock()
+			return value, true
+		}
+
+		if len(node.children) == 0 {
+			node.mu.RUnlock()
+			var zero V
+			return zero, false
+		}
+
+		child := node.children[i]
+		child.mu.RLock()
+		node.mu.RUnlock()
+		node = child
+	}
+}
+
+// Has reports whether the key is stored in the btree.
+func (lt *LatchTree[K, V]) Has(key K) bool {
+	_, found := lt.Get(key)
+	return found
+}
+
+// Put adds the key with the given value to the btree. If the key already exists,
+// its value is replaced and the previous value is returned together with true.
+func (lt *LatchTree[K, V]) Put(key K, value V) (V, bool) {
+	lt.mu.Lock()
+	if lt.root == nil {
+		lt.root = lt.newNode()
+	}
+
+	node := lt.root
+	node.mu.Lock()
+
+	// A full root is split before going down, so it is the only case where the
+	// btree grows a level.
+	if len(node.keys) == lt.maxKeys() {
+		newRoot := lt.newNode()
+		newRoot.children = append(newRoot.children, node)
+		newRoot.mu.Lock()
+		lt.splitChild(newRoot, 0)
+		node.mu.Unlock()
+
+		lt.root = newRoot
+		node = newRoot
+	}
+
+	// The root is not full anymore, so nothing below it can change the root pointer.
+	lt.mu.Unlock()
+
+	for {
+		i, found := node.find(key, lt.compare)
+		if found {
+			old := node.keys[i].V
+			node.keys[i].V = value
+			node.mu.Unlock()
+			return old, true
+		}
+
+		if len(node.children) == 0 {
+			node.insertKeyAt(i, Key[K, V]{K: key, V: value})
+			node.mu.Unlock()
+			lt.length.Add(1)
+			var zero V
+			return zero, false
+		}
+
+		child := node.children[i]
+		child.mu.Lock()
+
+		if len(child.keys) == lt.maxKeys() {
+			// The node has room for the middle key of the child since it is not full.
+			lt.splitChild(node, i)
+
+			switch c := lt.compare(key, node.keys[i].K); {
+			case c == 0:
+				child.mu.Unlock()
+				continue
+			case c > 0:
+				child.mu.Unlock()
+				child = node.children[i+1]
+				child.mu.Lock()
+			}
+		}
+
+		node.mu.Unlock()
+		node = child
+	}
+}
+
+// Delete deletes a key from the btree if found and returns its value. The second
+// value reports whether the key was found.
+func (lt *LatchTree[K, V]) Delete(key K) (V, bool) {
+	lt.mu.Lock()
+	node := lt.root
+	if node == nil {
+		lt.mu.Unlock()
+		var zero V
+		return zero, false
+	}
+	node.mu.Lock()
+
+	// rootLocked reports whether the root pointer is still latched. It is released
+	// after the first step, which is the only one that can empty the root.
+	rootLocked := true
+	release := func(next *latchNode[K, V]) {
+		if rootLocked {
+			// The root lost its last key in a merge, so its only child becomes
+			// the root.
+			if len(lt.root.keys) == 0 && len(lt.root.children) == 1 {
+				lt.root = lt.root.children[0]
+			}
+			lt.mu.Unlock()
+			rootLocked = false
+		}
+		node.mu.Unlock()
+		node = next
+	}
+
+	for {
+		i, found := node.find(key, lt.compare)
+
+		if len(node.children) == 0 {
+			var deleted Key[K, V]
+			if found {
+				deleted = node.removeKeyAt(i)
+				lt.length.Add(-1)
+			}
+			release(nil)
+			return deleted.V, found
+		}
+
+		if found {
+			deleted := node.keys[i]
+
+			// Replace the key with its predecessor or successor when the child it is
+			// taken from has keys to spare. The node stays latched until the key is
+			// replaced.
+			left := node.children[i]
+			left.mu.Lock()
+			if len(left.keys) > lt.minKeys() {
+				node.keys[i] = lt.removeMax(left)
+				lt.length.Add(-1)
+				release(nil)
+				return deleted.V, true
+			}
+
+			right := node.children[i+1]
+			right.mu.Lock()
+			if len(right.keys) > lt.minKeys() {
+				left.mu.Unlock()
+				node.keys[i] = lt.removeMin(right)
+				lt.length.Add(-1)
+				release(nil)
+				return deleted.V, true
+			}
+
+			// Both children are minimal, so they are merged around the key and the
+			// deletion continues in the merged node.
+			lt.mergeChildren(node, i)
+			release(left)
+			continue
+		}
+
+		release(lt.fillChild(node, i))
+	}
+}
+
+// removeMax removes and returns the largest key of the subtree rooted at node,
+// which must be latched and have more than the minimum number of keys. The latch
+// of node is released.
+func (lt *LatchTree[K, V]) removeMax(node *latchNode[K, V]) Key[K, V] {
+	for len(node.children) > 0 {
+		child := lt.fillChild(node, len(node.children)-1)
+		node.mu.Unlock()
+		node = child
+	}
+
+	key := node.removeKeyAt(len(node.keys) - 1)
+	node.mu.Unlock()
+	return key
+}
+
+// removeMin removes and returns the smallest key of the subtree rooted at node,
+// which must be latched and have more than the minimum number of keys. The latch
+// of node is released.
+func (lt *LatchTree[K, V]) removeMin(node *latchNode[K, V]) Key[K, V] {
+	for len(node.children) > 0 {
+		child := lt.fillChild(node, 0)
+		node.mu.Unlock()
+		node = child
+	}
+
+	key := node.removeKeyAt(0)
+	node.mu.Unlock()
+	return key
+}
+
+// fillChild latches the child at index i of node, which must be latched, and makes
+// sure it has more than the minimum number of keys by borrowing a key from one of
+// its siblings or by merging it with one of them. It returns the latched node the
+// descent must continue from.
+func (lt *LatchTree[K, V]) fillChild(node *latchNode[K, V], i int) *latchNode[K, V] {
+	child := node.children[i]
+	child.mu.Lock()
+	if len(child.keys) > lt.minKeys() {
+		return child
+	}
+
+	// Borrow from the left sibling through the parent key.
+	if i > 0 {
+		left := node.children[i-1]
+		left.mu.Lock()
+		if len(left.keys) > lt.minKeys() {
+			node.borrowFromLeft(i, &left.entries, &child.entries)
+			left.mu.Unlock()
+			return child
+		}
+
+		// The left sibling is minimal, if there is no right sibling the child is
+		// merged into it.
+		if i == len(node.keys) {
+			lt.mergeChildren(node, i-1)
+			return left
+		}
+		left.mu.Unlock()
+	}
+
+	// Borrow from the right sibling through the parent key.
+	right := node.children[i+1]
+	right.mu.Lock()
+	if len(right.keys) > lt.minKeys() {
+		node.borrowFromRight(i, &child.entries, &right.entries)
+		right.mu.Unlock()
+		return child
+	}
+
+	lt.mergeChildren(node, i)
+	return child
+}
+
+// mergeChildren merges the child at index i+1 of node and the key at index i into
+// the child at index i. The node and both children must be latched. The latch of
+// the merged right child is released, since the node is not reachable anymore.
+func (lt *LatchTree[K, V]) mergeChildren(node *latchNode[K, V], i int) {
+	left, right := node.children[i], node.children[i+1]
+	node.mergeChildren(i, &left.entries, &right.entries)
+	right.mu.Unlock()
+}
+
+// splitChild splits the full child at index i of node. The node and the child must
+// be latched. The middle key of the child moves to node and the keys bigger than
+// the middle key move to a new right sibling.
+func (lt *LatchTree[K, V]) splitChild(node *latchNode[K, V], i int) {
+	child, sibling := node.children[i], lt.newNode()
+	node.splitChild(i, &child.entries, &sibling.entries, sibling, lt.degree)
+}
+
+func (lt *LatchTree[K, V]) newNode() *latchNode[K, V] {
+	return &latchNode[K, V]{
+		entries: entries[K, V, *latchNode[K, V]]{
+			keys:     make([]Key[K, V], 0, 2*lt.degree-1),
+			children: make([]*latchNode[K, V], 0, 2*lt.degree),
+		},
+	}
+}
+
+func (lt *LatchTree[K, V]) maxKeys() int {
+	return 2*lt.degree - 1
+}
+
+func (lt *LatchTree[K, V]) minKeys() int {
+	return lt.degree - 1
+}
@@ -0,0 +1,237 @@
+package beetree
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// verifyLatchTree checks the btree invariants of a LatchTree and returns the number of keys in it
+func verifyLatchTree(t *testing.T, tree *LatchTree[int, int]) int {
+	t.Helper()
+
+	if tree.root == nil {
+		return 0
+	}
+
+	leafDepth := -1
+	var walk func(node *latchNode[int, int], depth int, lo, hi *int, isRoot bool) int
+	walk = func(node *latchNode[int, int], depth int, lo, hi *int, isRoot bool) int {
+		if !isRoot && len(node.keys) < tree.minKeys() {
+			t.Errorf("Non-root node has %d keys, minimum required: %d", len(node.keys), tree.minKeys())
+		}
+		if len(node.keys) > tree.maxKeys() {
+			t.Errorf("Node has %d keys, maximum allowed: %d", len(node.keys), tree.maxKeys())
+		}
+		for i, k := range node.keys {
+			if (i > 0 && node.keys[i-1].K >= k.K) || (lo != nil && k.K <= *lo) || (hi != nil && k.K >= *hi) {
+				t.Errorf("Key %d is out of order", k.K)
+			}
+		}
+
+		if len(node.children) == 0 {
+			if leafDepth == -1 {
+				leafDepth = depth
+			} else if leafDepth != depth {
+				t.Errorf("Leaf at depth %d, expected %d", depth, leafDepth)
+			}
+			return len(node.keys)
+		}
+
+		if len(node.children) != len(node.keys)+1 {
+			t.Errorf("Node has %d children, expected %d", len(node.children), len(node.keys)+1)
+			return len(node.keys)
+		}
+
+		count := len(node.keys)
+		for i, child := range node.children {
+			childLo, childHi := lo, hi
+			if i > 0 {
+				childLo = &node.keys[i-1].K
+			}
+			if i < len(node.keys) {
+				childHi = &node.keys[i].K
+			}
+			count += walk(child, depth+1, childLo, childHi, false)
+		}
+		return count
+	}
+
+	return walk(tree.root, 0, nil, nil, true)
+}
+
+// TestLatchTreeRandomOperations tests the LatchTree against a map from a single goroutine
+func TestLatchTreeRandomOperations(t *testing.T) {
+	for _, degree := range []int{2, 3, 5} {
+		r := rand.New(rand.NewSource(int64(degree)))
+		tree := NewLatchTree[int, int](degree)
+		expected := map[int]int{}
+
+		for i := 0; i < 5000; i++ {
+			k := r.Intn(500)
+			if r.Intn(3) == 0 {
+				v, found := tree.Delete(k)
+				ev, efound := expected[k]
+				if found != efound || v != ev {
+					t.Fatalf("degree %d: Delete(%d) = %d, %t, expected %d, %t", degree, k, v, found, ev, efound)
+				}
+				delete(expected, k)
+			} else {
+				old, replaced := tree.Put(k, i)
+				ev, efound := expected[k]
+				if replaced != efound || old != ev {
+					t.Fatalf("degree %d: Put(%d) = %d, %t, expected %d, %t", degree, k, old, replaced, ev, efound)
+				}
+				expected[k] = i
+			}
+		}
+
+		if count := verifyLatchTree(t, tree); count != len(expected) || tree.Len() != len(expected) {
+			t.Errorf("degree %d: Expected %d keys, got %d (length %d)", degree, len(expected), count, tree.Len())
+		}
+		for k, ev := range expected {
+			if v, found := tree.Get(k); !found || v != ev {
+				t.Errorf("degree %d: Expected value %d for key %d, got %d (found %t)", degree, ev, k, v, found)
+			}
+		}
+
+		// Deleting every key leaves an empty root.
+		for k := range expected {
+			tree.Delete(k)
+		}
+		if tree.Len() != 0 || len(tree.root.keys) != 0 || len(tree.root.children) != 0 {
+			t.Errorf("degree %d: Expected an empty tree", degree)
+		}
+	}
+}
+
+// TestLatchTreeConcurrentStress tests concurrent writers and readers and then checks the tree invariants.
+// Run it with -race to detect unsynchronized accesses.
+func TestLatchTreeConcurrentStress(t *testing.T) {
+	const writers, readers, keysPerWriter = 8, 4, 2000
+
+	tree := NewLatchTree[int, int](3)
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			// Every writer owns the keys congruent to w, inserts all of them and
+			// then deletes the odd ones.
+			for _, i := range rand.Perm(keysPerWriter) {
+				tree.Put(i*writers+w, w)
+			}
+			for i := 1; i < keysPerWriter; i += 2 {
+				if _, found := tree.Delete(i*writers + w); !found {
+					t.Errorf("writer %d: key %d not found", w, i*writers+w)
+					return
+				}
+			}
+		}(w)
+	}
+
+	done := make(chan struct{})
+	var readersWg sync.WaitGroup
+	for r := 0; r < readers; r++ {
+		readersWg.Add(1)
+		go func() {
+			defer readersWg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				k := rand.Intn(writers * keysPerWriter)
+				if v, found := tree.Get(k); found && v != k%writers {
+					t.Errorf("Expected value %d for key %d, got %d", k%writers, k, v)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	readersWg.Wait()
+
+	expected := writers * keysPerWriter / 2
+	if count := verifyLatchTree(t, tree); count != expected || tree.Len() != expected {
+		t.Errorf("Expected %d keys, got %d (length %d)", expected, count, tree.Len())
+	}
+	for k := 0; k < writers*keysPerWriter; k++ {
+		if found := tree.Has(k); found != ((k/writers)%2 == 0) {
+			t.Errorf("Unexpected presence %t for key %d", found, k)
+		}
+	}
+}
+
+// concurrentTree is the set of operations shared by SyncTree and LatchTree used by the benchmarks
+type concurrentTree interface {
+	Put(key int, value int) (int, bool)
+	Get(key int) (int, bool)
+	Delete(key int) (int, bool)
+}
+
+// benchmarkParallelMixed runs a workload of 50% reads, 25% inserts and 25% deletes from
+// GOMAXPROCS goroutines. Run it with -cpu 1,2,4,8 to see how throughput scales.
+func benchmarkParallelMixed(b *testing.B, tree concurrentTree) {
+	const keys = 100000
+	for _, k := range rand.Perm(keys) {
+		tree.Put(k, k)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			k := r.Intn(keys)
+			switch op := r.Intn(4); op {
+			case 0:
+				tree.Put(k, k)
+			case 1:
+				tree.Delete(k)
+			default:
+				tree.Get(k)
+			}
+		}
+	})
+}
+
+// benchmarkParallelWrites runs a workload of 50% inserts and 50% deletes from GOMAXPROCS goroutines.
+func benchmarkParallelWrites(b *testing.B, tree concurrentTree) {
+	const keys = 100000
+	for _, k := range rand.Perm(keys) {
+		tree.Put(k, k)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			k := r.Intn(keys)
+			if r.Intn(2) == 0 {
+				tree.Put(k, k)
+			} else {
+				tree.Delete(k)
+			}
+		}
+	})
+}
+
+func BenchmarkSyncTreeParallelMixed(b *testing.B) {
+	benchmarkParallelMixed(b, NewSyncTree[int, int](16))
+}
+
+func BenchmarkLatchTreeParallelMixed(b *testing.B) {
+	benchmarkParallelMixed(b, NewLatchTree[int, int](16))
+}
+
+func BenchmarkSyncTreeParallelWrites(b *testing.B) {
+	benchmarkParallelWrites(b, NewSyncTree[int, int](16))
+}
+
+func BenchmarkLatchTreeParallelWrites(b *testing.B) {
+	benchmarkParallelWrites(b, NewLatchTree[int, int](16))
+}
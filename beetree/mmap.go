@@ -0,0 +1,187 @@
+package beetree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrReadOnly is returned when a DiskTree opened in read-only mode is written.
+var ErrReadOnly = errors.New("beetree: tree is opened in read-only mode")
+
+// openMapped opens the file in read-only mode and maps it in memory.
+func openMapped[K, V any](path string, keyCodec Codec[K], valueCodec Codec[V], compare func(a, b K) int, opts *Options) (*DiskTree[K, V], error) {
+	// The WAL of a writer that crashed must be recovered, which writes the file.
+	if info, err := os.Stat(path + "-wal"); err == nil && info.Size() > 0 {
+		return nil, fmt.Errorf("beetree: %s has a WAL to recover, open it in read-write mode first", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dt, err := mapFile(f, keyCodec, valueCodec, compare, opts)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return dt, nil
+}
+
+func mapFile[K, V any](f *os.File, keyCodec Codec[K], valueCodec Codec[V], compare func(a, b K) int, opts *Options) (*DiskTree[K, V], error) {
+	dt, empty, err := openFile(f, keyCodec, valueCodec, compare, opts)
+	if err != nil {
+		return nil, err
+	}
+	if empty {
+		return nil, fmt.Errorf("%w: %s is empty", ErrCorrupt, f.Name())
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(info.Size()) < dt.meta.pageCount*uint64(dt.meta.pageSize) {
+		return nil, fmt.Errorf("%w: file has %d bytes, expected %d pages", ErrCorrupt, info.Size(), dt.meta.pageCount)
+	}
+
+	dt.mapped, err = mmap(f, int(info.Size()))
+	if err != nil {
+		return nil, fmt.Errorf("beetree: mapping %s: %w", f.Name(), err)
+	}
+	return dt, nil
+}
+
+// mappedGet is Get reading the mapped pages.
+func (dt *DiskTree[K, V]) mappedGet(key K) (V, bool, error) {
+	var zero V
+
+	id := dt.meta.root
+	for id != 0 {
+		page, n, err := dt.mappedPage(id)
+		if err != nil {
+			return zero, false, err
+		}
+
+		i, found, err := dt.mappedFind(page, n, key)
+		if err != nil {
+			return zero, false, err
+		}
+		if found {
+			v, err := dt.mappedValue(page, i)
+			return v, err == nil, err
+		}
+		if page[0] == pageLeaf {
+			break
+		}
+		id = dt.mappedChild(page, n, i)
+	}
+
+	return zero, false, nil
+}
+
+// mappedAscend is ascend reading the mapped pages.
+func (dt *DiskTree[K, V]) mappedAscend(id pageID, greaterOrEqual, lessThan *K, iterator ItemIterator[K, V]) (bool, error) {
+	page, n, err := dt.mappedPage(id)
+	if err != nil {
+		return false, err
+	}
+	leaf := page[0] == pageLeaf
+
+	start := 0
+	if greaterOrEqual != nil {
+		if start, _, err = dt.mappedFind(page, n, *greaterOrEqual); err != nil {
+			return false, err
+		}
+	}
+
+	for i := start; i <= n; i++ {
+		if !leaf {
+			if ok, err := dt.mappedAscend(dt.mappedChild(page, n, i), greaterOrEqual, lessThan, iterator); !ok || err != nil {
+				return false, err
+			}
+		}
+		if i == n {
+			break
+		}
+
+		k, err := dt.mappedKey(page, i)
+		if err != nil {
+			return false, err
+		}
+		if greaterOrEqual != nil && dt.compare(k, *greaterOrEqual) < 0 {
+			continue
+		}
+		if lessThan != nil && dt.compare(k, *lessThan) >= 0 {
+			return false, nil
+		}
+		v, err := dt.mappedValue(page, i)
+		if err != nil {
+			return false, err
+		}
+		if !iterator(k, v) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// mappedPage returns the mapped node page and its number of keys.
+func (dt *DiskTree[K, V]) mappedPage(id pageID) ([]byte, int, error) {
+	if id == 0 || uint64(id) >= dt.meta.pageCount {
+		return nil, 0, fmt.Errorf("%w: page %d out of range", ErrCorrupt, id)
+	}
+
+	offset := int(id) * dt.meta.pageSize
+	page := dt.mapped[offset : offset+dt.meta.pageSize]
+	if !validChecksum(page) {
+		return nil, 0, fmt.Errorf("%w: page %d checksum mismatch", ErrCorrupt, id)
+	}
+	n := int(binary.LittleEndian.Uint16(page[2:4]))
+	if (page[0] != pageLeaf && page[0] != pageInner) || n > dt.maxKeys() {
+		return nil, 0, fmt.Errorf("%w: page %d is not a valid node", ErrCorrupt, id)
+	}
+	return page, n, nil
+}
+
+// mappedFind is find on a mapped page with n keys.
+func (dt *DiskTree[K, V]) mappedFind(page []byte, n int, key K) (int, bool, error) {
+	lo, hi := 0, n
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		k, err := dt.mappedKey(page, mid)
+		if err != nil {
+			return 0, false, err
+		}
+
+		switch c := dt.compare(k, key); {
+		case c == 0:
+			return mid, true, nil
+		case c < 0:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+
+	return lo, false, nil
+}
+
+func (dt *DiskTree[K, V]) mappedKey(page []byte, i int) (K, error) {
+	offset := nodeHeaderSize + i*(dt.meta.keySize+dt.meta.valueSize)
+	return dt.keyCodec.Decode(page[offset : offset+dt.meta.keySize])
+}
+
+func (dt *DiskTree[K, V]) mappedValue(page []byte, i int) (V, error) {
+	offset := nodeHeaderSize + i*(dt.meta.keySize+dt.meta.valueSize) + dt.meta.keySize
+	return dt.valueCodec.Decode(page[offset : offset+dt.meta.valueSize])
+}
+
+// mappedChild returns the child at index i of a mapped inner page with n keys.
+func (dt *DiskTree[K, V]) mappedChild(page []byte, n, i int) pageID {
+	offset := nodeHeaderSize + n*(dt.meta.keySize+dt.meta.valueSize) + i*8
+	return pageID(binary.LittleEndian.Uint64(page[offset:]))
+}
@@ -0,0 +1,17 @@
+//go:build linux
+
+package beetree
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmap maps the first size bytes of the file in memory for reading.
+func mmap(f *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+func munmap(data []byte) error {
+	return syscall.Munmap(data)
+}
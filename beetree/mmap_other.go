@@ -0,0 +1,18 @@
+//go:build !linux
+
+package beetree
+
+import (
+	"errors"
+	"os"
+)
+
+var errMmapUnsupported = errors.New("beetree: read-only mode is only supported on linux")
+
+func mmap(f *os.File, size int) ([]byte, error) {
+	return nil, errMmapUnsupported
+}
+
+func munmap(data []byte) error {
+	return errMmapUnsupported
+}
@@ -0,0 +1,150 @@
+//go:build linux
+
+package beetree
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestDiskTreeReadOnly tests that a file opened in read-only mode answers reads from the mapped
+// pages like the read-write mode does and rejects writes
+func TestDiskTreeReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree := openIntTree(t, path, smallPages)
+	for i := 0; i < 3000; i += 3 {
+		tree.Put(i, -i)
+	}
+	if err := tree.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	mapped := openIntTree(t, path, &Options{ReadOnly: true})
+	if mapped.Len() != 1000 {
+		t.Errorf("Expected 1000 keys, got %d", mapped.Len())
+	}
+
+	// Readers only read the mapped file, so they can run concurrently.
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for k := g; k < 3000; k += 4 {
+				v, found, err := mapped.Get(k)
+				if err != nil || found != (k%3 == 0) || (found && v != -k) {
+					t.Errorf("Get(%d) = %d, %t, %v", k, v, found, err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	var keys []int
+	err := mapped.AscendRange(100, 200, func(k, v int) bool {
+		if v != -k {
+			t.Errorf("Unexpected value %d for key %d", v, k)
+		}
+		keys = append(keys, k)
+		return true
+	})
+	if err != nil || len(keys) != 33 || keys[0] != 102 || keys[32] != 198 {
+		t.Errorf("Unexpected keys %v (error %v)", keys, err)
+	}
+
+	if _, _, err := mapped.Put(1, 1); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly from Put, got %v", err)
+	}
+	if _, _, err := mapped.Delete(0); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly from Delete, got %v", err)
+	}
+	if err := mapped.Compact(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly from Compact, got %v", err)
+	}
+	if found, _ := mapped.Has(0); !found {
+		t.Errorf("Expected key 0 to be found after the rejected Delete")
+	}
+
+	// Both modes share the file format, so the file can be written again once the
+	// readers are done.
+	if err := mapped.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	writer := openIntTree(t, path, nil)
+	writer.Put(1, 1)
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	mapped = openIntTree(t, path, &Options{ReadOnly: true})
+	defer mapped.Close()
+	if v, found, _ := mapped.Get(1); !found || v != 1 {
+		t.Errorf("Expected value 1 for key 1, got %d (found %t)", v, found)
+	}
+}
+
+// TestDiskTreeReadOnlyDetectsCorruptPages tests that read-only mode checks the checksum of the
+// mapped pages before reading them
+func TestDiskTreeReadOnlyDetectsCorruptPages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree := openIntTree(t, path, smallPages)
+	for i := 0; i < 1000; i++ {
+		tree.Put(i, i)
+	}
+	root := tree.meta.root
+	if err := tree.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// A single byte of the first key of the root is flipped.
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset := int64(root)*int64(smallPages.PageSize) + nodeHeaderSize
+	b := make([]byte, 1)
+	f.ReadAt(b, offset)
+	b[0] ^= 0x01
+	f.WriteAt(b, offset)
+	f.Close()
+
+	mapped := openIntTree(t, path, &Options{ReadOnly: true})
+	defer mapped.Close()
+	if _, _, err := mapped.Get(500); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt from Get, got %v", err)
+	}
+	if err := mapped.Ascend(func(k, v int) bool { return true }); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt from Ascend, got %v", err)
+	}
+}
+
+// TestDiskTreeReadOnlyNeedsRecoveredFile tests that read-only mode refuses a file with a WAL left
+// by a writer that crashed, and a file that does not exist
+func TestDiskTreeReadOnlyNeedsRecoveredFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree := openIntTree(t, path, smallPages)
+	tree.Put(1, 1)
+	// The writer dies without closing the tree.
+	tree.file.file.Close()
+	tree.wal.file.Close()
+
+	if _, err := Open[int, int](path, IntCodec{}, IntCodec{}, &Options{ReadOnly: true}); err == nil {
+		t.Errorf("Expected an error opening a file with a WAL to recover")
+	}
+
+	tree = openIntTree(t, path, nil)
+	tree.Close()
+	mapped := openIntTree(t, path, &Options{ReadOnly: true})
+	if found, _ := mapped.Has(1); !found {
+		t.Errorf("Expected key 1 to be found after recovery")
+	}
+	mapped.Close()
+
+	if _, err := Open[int, int](filepath.Join(t.TempDir(), "missing.db"), IntCodec{}, IntCodec{}, &Options{ReadOnly: true}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist opening a missing file, got %v", err)
+	}
+}
@@ -0,0 +1,376 @@
+package beetree
+
+import (
+	"container/list"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+var (
+	// ErrCorrupt is returned when a page of a DiskTree file fails validation.
+	ErrCorrupt = errors.New("beetree: corrupt file")
+
+	// ErrClosed is returned when a DiskTree is used after Close.
+	ErrClosed = errors.New("beetree: tree is closed")
+
+	// ErrFailed is returned when a DiskTree is used after a write failed to be
+	// logged. The btree must be closed and opened again, which recovers the
+	// writes committed before the failure.
+	ErrFailed = errors.New("beetree: a write failed, the tree must be reopened")
+)
+
+// pageID identifies a page by its position in the file. The page 0 is the meta page,
+// so 0 is also used as the nil page.
+type pageID uint64
+
+const (
+	// DefaultPageSize is the page size of new files when none is given.
+	DefaultPageSize = 4096
+
+	// DefaultCacheSize is the number of pages kept in memory when none is given.
+	DefaultCacheSize = 128
+
+	// minPageSize is the smallest page size that fits the meta page.
+	minPageSize = 128
+
+	// maxPageSize is the largest page size of a file.
+	maxPageSize = 1 << 20
+
+	// minCacheSize is the number of pages an operation may have pinned at once,
+	// plus some room.
+	minCacheSize = 8
+
+	formatVersion = 1
+)
+
+var fileMagic = [8]byte{'B', 'E', 'E', 'T', 'R', 'E', 'E', 0}
+
+// Page kinds, stored in the first byte of every node page.
+const (
+	pageLeaf  byte = 1
+	pageInner byte = 2
+	pageFree  byte = 3
+)
+
+// Layout of a node page. The checksum covers the rest of the page.
+//
+//	[0]          kind
+//	[1]          reserved
+//	[2:4]        number of keys n
+//	[4:]         n entries, the key followed by the value
+//	             n+1 children page ids, for inner pages only
+//	[size-4:]    crc32 checksum
+//
+// Free pages form a linked list starting at the meta page. A free page stores the
+// id of the next free page in [4:12].
+const nodeHeaderSize = 4
+
+// meta is the content of the meta page.
+//
+//	[0:8]        magic
+//	[8:12]       format version
+//	[12:16]      page size
+//	[16:20]      key size
+//	[20:24]      value size
+//	[24:32]      root page id
+//	[32:40]      number of pages
+//	[40:48]      number of keys
+//	[48:56]      first free page id
+//	[56:64]      number of free pages
+//	[size-4:]    crc32 checksum
+type meta struct {
+	pageSize  int
+	keySize   int
+	valueSize int
+	root      pageID
+	pageCount uint64
+	length    uint64
+	freeHead  pageID
+	freeCount uint64
+}
+
+func (m *meta) encode(page []byte) {
+	clear(page)
+	copy(page[0:8], fileMagic[:])
+	binary.LittleEndian.PutUint32(page[8:12], formatVersion)
+	binary.LittleEndian.PutUint32(page[12:16], uint32(m.pageSize))
+	binary.LittleEndian.PutUint32(page[16:20], uint32(m.keySize))
+	binary.LittleEndian.PutUint32(page[20:24], uint32(m.valueSize))
+	binary.LittleEndian.PutUint64(page[24:32], uint64(m.root))
+	binary.LittleEndian.PutUint64(page[32:40], m.pageCount)
+	binary.LittleEndian.PutUint64(page[40:48], m.length)
+	binary.LittleEndian.PutUint64(page[48:56], uint64(m.freeHead))
+	binary.LittleEndian.PutUint64(page[56:64], m.freeCount)
+	putChecksum(page)
+}
+
+func (m *meta) decode(page []byte) error {
+	if [8]byte(page[0:8]) != fileMagic {
+		return fmt.Errorf("%w: bad magic", ErrCorrupt)
+	}
+	if v := binary.LittleEndian.Uint32(page[8:12]); v != formatVersion {
+		return fmt.Errorf("%w: unsupported format version %d", ErrCorrupt, v)
+	}
+	if !validChecksum(page) {
+		return fmt.Errorf("%w: meta page checksum mismatch", ErrCorrupt)
+	}
+
+	m.pageSize = int(binary.LittleEndian.Uint32(page[12:16]))
+	m.keySize = int(binary.LittleEndian.Uint32(page[16:20]))
+	m.valueSize = int(binary.LittleEndian.Uint32(page[20:24]))
+	m.root = pageID(binary.LittleEndian.Uint64(page[24:32]))
+	m.pageCount = binary.LittleEndian.Uint64(page[32:40])
+	m.length = binary.LittleEndian.Uint64(page[40:48])
+	m.freeHead = pageID(binary.LittleEndian.Uint64(page[48:56]))
+	m.freeCount = binary.LittleEndian.Uint64(page[56:64])
+	return nil
+}
+
+// readMeta reads the meta page from the start of the file. The page size is not
+// known before, so the fixed part of the meta page is read first.
+func readMeta(f io.ReaderAt) (meta, error) {
+	var m meta
+
+	head := make([]byte, minPageSize)
+	if _, err := f.ReadAt(head, 0); err != nil {
+		return m, fmt.Errorf("%w: reading meta page: %v", ErrCorrupt, err)
+	}
+	pageSize := int(binary.LittleEndian.Uint32(head[12:16]))
+	if pageSize < minPageSize || pageSize > maxPageSize {
+		return m, fmt.Errorf("%w: bad page size %d", ErrCorrupt, pageSize)
+	}
+
+	page := make([]byte, pageSize)
+	if _, err := f.ReadAt(page, 0); err != nil {
+		return m, fmt.Errorf("%w: reading meta page: %v", ErrCorrupt, err)
+	}
+	err := m.decode(page)
+	return m, err
+}
+
+func putChecksum(page []byte) {
+	n := len(page) - 4
+	binary.LittleEndian.PutUint32(page[n:], crc32.ChecksumIEEE(page[:n]))
+}
+
+func validChecksum(page []byte) bool {
+	n := len(page) - 4
+	return binary.LittleEndian.Uint32(page[n:]) == crc32.ChecksumIEEE(page[:n])
+}
+
+// storageFile is the part of *os.File used by a DiskTree. Tests replace it to
+// inject faults.
+type storageFile interface {
+	io.ReaderAt
+	io.WriterAt
+	Sync() error
+	Truncate(size int64) error
+	Stat() (os.FileInfo, error)
+	Close() error
+}
+
+// pageFile reads and writes whole pages of a file.
+type pageFile struct {
+	file     storageFile
+	pageSize int
+}
+
+func (pf *pageFile) readPage(id pageID, page []byte) error {
+	_, err := pf.file.ReadAt(page, int64(id)*int64(pf.pageSize))
+	if err != nil {
+		return fmt.Errorf("beetree: reading page %d: %w", id, err)
+	}
+	return nil
+}
+
+func (pf *pageFile) writePage(id pageID, page []byte) error {
+	_, err := pf.file.WriteAt(page, int64(id)*int64(pf.pageSize))
+	if err != nil {
+		return fmt.Errorf("beetree: writing page %d: %w", id, err)
+	}
+	return nil
+}
+
+// diskNode is a node of a DiskTree decoded from its page, or a free page.
+type diskNode[K, V any] struct {
+	id pageID
+	entries[K, V, pageID]
+
+	// free reports whether the page is in the free list, next is the next free
+	// page.
+	free bool
+	next pageID
+}
+
+// frame is a node held in the buffer pool.
+type frame[K, V any] struct {
+	node  *diskNode[K, V]
+	pins  int
+	dirty bool
+	// uncommitted reports whether the node was modified by the operation in
+	// progress. Such nodes are not evicted until the operation is logged.
+	uncommitted bool
+	// elem is the position of the frame in the LRU list while it is not pinned.
+	elem *list.Element
+}
+
+// bufferPool keeps up to capacity nodes in memory. Nodes are pinned while they are
+// being used and only unpinned nodes are evicted, the least recently used first.
+// Dirty nodes are written back when they are evicted or flushed.
+//
+// The nodes modified by the operation in progress are not evicted either, the pool
+// grows over its capacity if there is nothing else to evict.
+type bufferPool[K, V any] struct {
+	capacity int
+	frames   map[pageID]*frame[K, V]
+	// lru holds the unpinned frames, the most recently used at the front.
+	lru *list.List
+	// uncommitted holds the frames modified by the operation in progress.
+	uncommitted []*frame[K, V]
+
+	load  func(id pageID) (*diskNode[K, V], error)
+	write func(node *diskNode[K, V]) error
+}
+
+func newBufferPool[K, V any](capacity int, load func(pageID) (*diskNode[K, V], error), write func(*diskNode[K, V]) error) *bufferPool[K, V] {
+	return &bufferPool[K, V]{
+		capacity: capacity,
+		frames:   make(map[pageID]*frame[K, V], capacity),
+		lru:      list.New(),
+		load:     load,
+		write:    write,
+	}
+}
+
+// fetch returns the pinned node of the page, loading it if it is not in memory.
+func (bp *bufferPool[K, V]) fetch(id pageID) (*diskNode[K, V], error) {
+	if f, ok := bp.frames[id]; ok {
+		bp.pin(f)
+		return f.node, nil
+	}
+
+	if err := bp.makeRoom(); err != nil {
+		return nil, err
+	}
+	node, err := bp.load(id)
+	if err != nil {
+		return nil, err
+	}
+
+	bp.frames[id] = &frame[K, V]{node: node, pins: 1}
+	return node, nil
+}
+
+// add adds the new node of a page that is not in the file yet. It is returned
+// pinned and dirty.
+func (bp *bufferPool[K, V]) add(node *diskNode[K, V]) error {
+	if err := bp.makeRoom(); err != nil {
+		return err
+	}
+
+	f := &frame[K, V]{node: node, pins: 1}
+	bp.frames[node.id] = f
+	bp.markFrameDirty(f)
+	return nil
+}
+
+// unpin releases a pinned node.
+func (bp *bufferPool[K, V]) unpin(node *diskNode[K, V]) {
+	f := bp.frames[node.id]
+	if f == nil || f.pins == 0 {
+		panic("beetree: unpin of a page that is not pinned")
+	}
+
+	f.pins--
+	if f.pins == 0 {
+		f.elem = bp.lru.PushFront(f)
+	}
+}
+
+// markDirty marks the pinned node as modified, so it is written back before it is
+// evicted.
+func (bp *bufferPool[K, V]) markDirty(node *diskNode[K, V]) {
+	bp.markFrameDirty(bp.frames[node.id])
+}
+
+func (bp *bufferPool[K, V]) markFrameDirty(f *frame[K, V]) {
+	f.dirty = true
+	if !f.uncommitted {
+		f.uncommitted = true
+		bp.uncommitted = append(bp.uncommitted, f)
+	}
+}
+
+// modified returns the nodes modified by the operation in progress, which are
+// still in the pool.
+func (bp *bufferPool[K, V]) modified() []*diskNode[K, V] {
+	var nodes []*diskNode[K, V]
+	for _, f := range bp.uncommitted {
+		if bp.frames[f.node.id] == f {
+			nodes = append(nodes, f.node)
+		}
+	}
+	return nodes
+}
+
+// commit marks the nodes modified by the operation in progress as logged, so they
+// can be evicted.
+func (bp *bufferPool[K, V]) commit() {
+	for _, f := range bp.uncommitted {
+		f.uncommitted = false
+	}
+	clear(bp.uncommitted)
+	bp.uncommitted = bp.uncommitted[:0]
+}
+
+// flush writes back every dirty node.
+func (bp *bufferPool[K, V]) flush() error {
+	for _, f := range bp.frames {
+		if !f.dirty {
+			continue
+		}
+		if err := bp.write(f.node); err != nil {
+			return err
+		}
+		f.dirty = false
+	}
+	return nil
+}
+
+func (bp *bufferPool[K, V]) pin(f *frame[K, V]) {
+	if f.pins == 0 {
+		bp.lru.Remove(f.elem)
+		f.elem = nil
+	}
+	f.pins++
+}
+
+// makeRoom evicts the least recently used unpinned node if the pool is full.
+func (bp *bufferPool[K, V]) makeRoom() error {
+	if len(bp.frames) < bp.capacity {
+		return nil
+	}
+
+	for elem := bp.lru.Back(); elem != nil; elem = elem.Prev() {
+		f := elem.Value.(*frame[K, V])
+		if f.uncommitted {
+			continue
+		}
+
+		if f.dirty {
+			if err := bp.write(f.node); err != nil {
+				return err
+			}
+			f.dirty = false
+		}
+		bp.lru.Remove(elem)
+		delete(bp.frames, f.node.id)
+		return nil
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package beetree
+
+// Union returns a new btree with the keys of a and b. The value of a key in
+// both btrees is the one of a.
+//
+// Both btrees must have the same ordering. The new btree has the degree and
+// the ordering of a. Like the other set operations, Union runs in linear time:
+// it merges the keys of both btrees in order and builds the new btree bottom-up.
+func Union[K, V any](a, b *BeeTree[K, V]) *BeeTree[K, V] {
+	return mergeTrees(a, b, true, true, true)
+}
+
+// Intersection returns a new btree with the keys that are both in a and b, with
+// the values of a. Both btrees must have the same ordering.
+func Intersection[K, V any](a, b *BeeTree[K, V]) *BeeTree[K, V] {
+	return mergeTrees(a, b, false, false, true)
+}
+
+// Difference returns a new btree with the keys of a that are not in b. Both
+// btrees must have the same ordering.
+func Difference[K, V any](a, b *BeeTree[K, V]) *BeeTree[K, V] {
+	return mergeTrees(a, b, true, false, false)
+}
+
+// SymmetricDifference returns a new btree with the keys that are either in a
+// or in b, but not in both. Both btrees must have the same ordering.
+func SymmetricDifference[K, V any](a, b *BeeTree[K, V]) *BeeTree[K, V] {
+	return mergeTrees(a, b, true, true, false)
+}
+
+// mergeTrees walks the keys of a and b in order with a cursor on each btree and
+// returns a new btree with the keys only in a if onlyA is true, the keys only in
+// b if onlyB is true and the keys in both if both is true.
+func mergeTrees[K, V any](a, b *BeeTree[K, V], onlyA, onlyB, both bool) *BeeTree[K, V] {
+	out := NewBeetreeFunc[K, V](a.Degree, a.compare)
+
+	var keys []Key[K, V]
+	ca, cb := a.Cursor(), b.Cursor()
+	inA, inB := ca.First(), cb.First()
+	for inA || inB {
+		var c int
+		switch {
+		case !inB:
+			c = -1
+		case !inA:
+			c = 1
+		default:
+			c = a.compare(ca.Key(), cb.Key())
+		}
+
+		switch {
+		case c < 0:
+			if onlyA {
+				keys = append(keys, Key[K, V]{K: ca.Key(), V: ca.Value()})
+			}
+			inA = ca.Next()
+		case c > 0:
+			if onlyB {
+				keys = append(keys, Key[K, V]{K: cb.Key(), V: cb.Value()})
+			}
+			inB = cb.Next()
+		default:
+			if both {
+				keys = append(keys, Key[K, V]{K: ca.Key(), V: ca.Value()})
+			}
+			inA, inB = ca.Next(), cb.Next()
+		}
+	}
+
+	out.Root = out.build(keys, 2*out.Degree-1)
+	out.length = len(keys)
+	return out
+}
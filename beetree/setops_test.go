@@ -0,0 +1,103 @@
+package beetree
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestSetOperations tests the set operations against maps, for random trees that overlap partially
+func TestSetOperations(t *testing.T) {
+	for round := 0; round < 50; round++ {
+		a, b := NewBeetree[int, int](2+rand.Intn(4)), NewBeetree[int, int](2+rand.Intn(4))
+		inA, inB := map[int]int{}, map[int]int{}
+		for i := rand.Intn(1000); i > 0; i-- {
+			k := rand.Intn(1000)
+			a.Put(k, k)
+			inA[k] = k
+		}
+		for i := rand.Intn(1000); i > 0; i-- {
+			k := rand.Intn(1000)
+			b.Put(k, -k)
+			inB[k] = -k
+		}
+		// Trees emptied by deletes are empty sets too.
+		if round%10 == 0 {
+			for k := range inA {
+				a.Delete(k)
+			}
+			inA = map[int]int{}
+		}
+
+		union, intersection, difference, symmetric := map[int]int{}, map[int]int{}, map[int]int{}, map[int]int{}
+		for k, v := range inB {
+			union[k] = v
+			if _, found := inA[k]; !found {
+				symmetric[k] = v
+			}
+		}
+		for k, v := range inA {
+			union[k] = v
+			if _, found := inB[k]; found {
+				intersection[k] = v
+			} else {
+				difference[k] = v
+				symmetric[k] = v
+			}
+		}
+
+		for name, c := range map[string]struct {
+			tree     *BeeTree[int, int]
+			expected map[int]int
+		}{
+			"Union":               {Union(a, b), union},
+			"Intersection":        {Intersection(a, b), intersection},
+			"Difference":          {Difference(a, b), difference},
+			"SymmetricDifference": {SymmetricDifference(a, b), symmetric},
+		} {
+			if !reflect.DeepEqual(collectPairs(c.tree), c.expected) || c.tree.Len() != len(c.expected) {
+				t.Fatalf("Round %d: %s has unexpected keys", round, name)
+			}
+			if c.tree.Degree != a.Degree {
+				t.Errorf("Round %d: %s has degree %d, expected %d", round, name, c.tree.Degree, a.Degree)
+			}
+			if err := c.tree.Verify(); err != nil {
+				t.Fatalf("Round %d: Verify failed on %s: %v", round, name, err)
+			}
+		}
+
+		if !reflect.DeepEqual(collectPairs(a), inA) || !reflect.DeepEqual(collectPairs(b), inB) {
+			t.Fatalf("Round %d: set operations modified their input trees", round)
+		}
+	}
+}
+
+// TestSetOperationsWithComparator tests that the result trees keep the ordering of the inputs
+func TestSetOperationsWithComparator(t *testing.T) {
+	descending := func(a, b string) int { return strings.Compare(b, a) }
+	a, b := NewBeetreeFunc[string, int](2, descending), NewBeetreeFunc[string, int](3, descending)
+	for i, s := range []string{"a", "b", "c", "d"} {
+		a.Put(s, i)
+	}
+	for i, s := range []string{"c", "d", "e"} {
+		b.Put(s, 10+i)
+	}
+
+	union := Union(a, b)
+	var keys []string
+	for k := range union.All() {
+		keys = append(keys, k)
+	}
+	if !reflect.DeepEqual(keys, []string{"e", "d", "c", "b", "a"}) {
+		t.Errorf("Unexpected keys %q", keys)
+	}
+	if v, _ := union.Get("c"); v != 2 {
+		t.Errorf("Expected the value of the first tree for a key in both, got %d", v)
+	}
+
+	union.Put("f", 5)
+	if k, _, _ := union.Min(); k != "f" {
+		t.Errorf("Expected f to be the smallest key in descending order, got %q", k)
+	}
+}
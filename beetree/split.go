@@ -0,0 +1,191 @@
+package beetree
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrJoinOrder is returned by Join when a key of the left btree is not less
+// than every key of the right btree.
+var ErrJoinOrder = errors.New("beetree: keys of the left btree are not all less than the keys of the right btree")
+
+// SplitAt returns two new btrees: the first one with the keys of the btree that
+// are less than key, the second one with the keys that are greater or equal.
+// The btree is not changed.
+//
+// SplitAt runs in O(log n): the subtrees on both sides of the path to key are
+// shared with the btree and grafted together, only the nodes on the path are
+// copied.
+func (bt *BeeTree[K, V]) SplitAt(key K) (*BeeTree[K, V], *BeeTree[K, V]) {
+	// The clone takes the ownership of the nodes from bt, so that the new btrees
+	// can share them safely.
+	src := bt.Clone()
+	left := NewBeetreeFunc[K, V](bt.Degree, bt.compare)
+	right := NewBeetreeFunc[K, V](bt.Degree, bt.compare)
+	if src.Len() == 0 {
+		return left, right
+	}
+
+	l, _, r, _ := splitSubtree(left, right, src.Root, src.Root.height(), key)
+	left.setRoot(l)
+	right.setRoot(r)
+	return left, right
+}
+
+// Join returns a new btree with the keys of left and right. Every key of left
+// must be less than every key of right, otherwise Join returns ErrJoinOrder.
+// Both btrees must have the same degree and ordering; the new btree has the
+// ordering of left. left and right are not changed.
+//
+// Join runs in O(log n): the shorter btree is grafted on the side of the taller
+// one at the same height, and only the nodes on that side are copied.
+func Join[K, V any](left, right *BeeTree[K, V]) (*BeeTree[K, V], error) {
+	if left.Degree != right.Degree {
+		return nil, fmt.Errorf("beetree: cannot join btrees of degree %d and %d", left.Degree, right.Degree)
+	}
+	l, r := left.Clone(), right.Clone()
+	if r.Len() == 0 {
+		return l, nil
+	}
+	if l.Len() == 0 {
+		return r, nil
+	}
+	maxKey, _, _ := l.Max()
+	minKey, _, _ := r.Min()
+	if l.compare(maxKey, minKey) >= 0 {
+		return nil, fmt.Errorf("%w: %v is not less than %v", ErrJoinOrder, maxKey, minKey)
+	}
+
+	// The smallest key of right separates both btrees.
+	k, v, _ := r.DeleteMin()
+	rightRoot := r.Root
+	if r.Len() == 0 {
+		rightRoot = l.newNode()
+	}
+	l.version++
+	l.length += r.length + 1
+	l.Root, _ = l.joinWithKey(l.Root, l.Root.height(), Key[K, V]{K: k, V: v}, rightRoot, rightRoot.height())
+	return l, nil
+}
+
+// height returns the number of levels of the subtree rooted at the node, 1 for
+// a leaf.
+func (n *Node[K, V]) height() int {
+	h := 1
+	for ; len(n.Children) > 0; n = n.Children[0] {
+		h++
+	}
+	return h
+}
+
+// setRoot makes node the root of the btree, which is empty if the node is a
+// leaf without keys.
+func (bt *BeeTree[K, V]) setRoot(node *Node[K, V]) {
+	bt.Root = node
+	if len(node.Keys) == 0 {
+		bt.Root = nil
+	}
+	bt.length = node.size
+}
+
+// splitSubtree splits the subtree rooted at node, of height h, between the
+// keys less than key and the keys greater or equal. It returns the roots of
+// both parts with their heights, the first one owned by left and the second one
+// by right. A part without keys is a leaf without keys.
+func splitSubtree[K, V any](left, right *BeeTree[K, V], node *Node[K, V], h int, key K) (*Node[K, V], int, *Node[K, V], int) {
+	i, found := slices.BinarySearchFunc(node.Keys, key, func(k Key[K, V], key K) int { return left.compare(k.K, key) })
+
+	if len(node.Children) == 0 {
+		l, r := left.newNode(), right.newNode()
+		l.Keys = append(l.Keys, node.Keys[:i]...)
+		r.Keys = append(r.Keys, node.Keys[i:]...)
+		l.updateSize()
+		r.updateSize()
+		return l, 1, r, 1
+	}
+
+	if found {
+		// The key itself becomes the smallest key of the right part.
+		l, lh := left.fragment(node.Keys[:i], node.Children[:i+1], h)
+		rf, rfh := right.fragment(node.Keys[i+1:], node.Children[i+1:], h)
+		r, rh := right.joinWithKey(right.newNode(), 1, node.Keys[i], rf, rfh)
+		return l, lh, r, rh
+	}
+
+	// The child on the path to key is split, and its parts are joined with the
+	// keys and children of the node on their side.
+	l, lh, r, rh := splitSubtree(left, right, node.Children[i], h-1, key)
+	if i > 0 {
+		lf, lfh := left.fragment(node.Keys[:i-1], node.Children[:i], h)
+		l, lh = left.joinWithKey(lf, lfh, node.Keys[i-1], l, lh)
+	}
+	if i < len(node.Keys) {
+		rf, rfh := right.fragment(node.Keys[i+1:], node.Children[i+1:], h)
+		r, rh = right.joinWithKey(r, rh, node.Keys[i], rf, rfh)
+	}
+	return l, lh, r, rh
+}
+
+// fragment returns the root of a subtree with the keys and children of a node
+// of height h, and its height. Without keys, the single child is the root.
+func (bt *BeeTree[K, V]) fragment(keys []Key[K, V], children []*Node[K, V], h int) (*Node[K, V], int) {
+	if len(keys) == 0 {
+		return children[0], h - 1
+	}
+	n := bt.newNode()
+	n.Keys = append(n.Keys, keys...)
+	n.Children = append(n.Children, children...)
+	n.updateSize()
+	return n, h
+}
+
+// joinWithKey joins the subtrees rooted at left and right, of heights lh and
+// rh, with the key between them, and returns the root of the result and its
+// height. Either subtree can be a leaf without keys.
+func (bt *BeeTree[K, V]) joinWithKey(left *Node[K, V], lh int, key Key[K, V], right *Node[K, V], rh int) (*Node[K, V], int) {
+	var nodes []*Node[K, V]
+	var separators []Key[K, V]
+	if lh >= rh {
+		nodes, separators = bt.graftRight(left.mutableFor(bt.cow), lh, key, right, rh)
+	} else {
+		nodes, separators = bt.graftLeft(right.mutableFor(bt.cow), rh, key, left, lh)
+	}
+	root, levels := bt.newRoot(nodes, separators)
+	return root, max(lh, rh) + levels
+}
+
+// graftRight adds the key and the subtree rooted at other, of height oh, after
+// the last key of the subtree rooted at node, of height h >= oh, which must be
+// owned by the btree. other is merged with the node at its height on the right
+// side. Since that node is valid or the root, no node is left with too few keys,
+// and the nodes that get too many are split on the way up. The nodes returned
+// are like the ones of applyBatch.
+func (bt *BeeTree[K, V]) graftRight(node *Node[K, V], h int, key Key[K, V], other *Node[K, V], oh int) ([]*Node[K, V], []Key[K, V]) {
+	if h == oh {
+		node.Keys = append(append(node.Keys, key), other.Keys...)
+		node.Children = append(node.Children, other.Children...)
+		return bt.splitNode(node)
+	}
+
+	last := len(node.Children) - 1
+	nodes, separators := bt.graftRight(node.mutableChild(last, bt.cow), h-1, key, other, oh)
+	node.Keys = append(node.Keys, separators...)
+	node.Children = append(node.Children[:last], nodes...)
+	return bt.splitNode(node)
+}
+
+// graftLeft is graftRight for a subtree other added before the first key of
+// the subtree rooted at node.
+func (bt *BeeTree[K, V]) graftLeft(node *Node[K, V], h int, key Key[K, V], other *Node[K, V], oh int) ([]*Node[K, V], []Key[K, V]) {
+	if h == oh {
+		node.Keys = slices.Concat(other.Keys, []Key[K, V]{key}, node.Keys)
+		node.Children = slices.Concat(other.Children, node.Children)
+		return bt.splitNode(node)
+	}
+
+	nodes, separators := bt.graftLeft(node.mutableChild(0, bt.cow), h-1, key, other, oh)
+	node.Keys = slices.Concat(separators, node.Keys)
+	node.Children = slices.Concat(nodes, node.Children[1:])
+	return bt.splitNode(node)
+}
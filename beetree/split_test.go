@@ -0,0 +1,158 @@
+package beetree
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// nodeSet returns the nodes of the subtree rooted at node
+func nodeSet[K, V any](node *Node[K, V], nodes map[*Node[K, V]]bool) map[*Node[K, V]]bool {
+	if node == nil {
+		return nodes
+	}
+	nodes[node] = true
+	for _, c := range node.Children {
+		nodeSet(c, nodes)
+	}
+	return nodes
+}
+
+// TestSplitAt tests splitting trees of all degrees at keys inside and outside of them
+func TestSplitAt(t *testing.T) {
+	for degree := 2; degree <= 5; degree++ {
+		for _, n := range []int{0, 1, 10, 100, 1000} {
+			tree := NewBeetree[int, int](degree)
+			for _, i := range rand.Perm(n) {
+				tree.Put(2*i, i)
+			}
+			before := collectPairs(tree)
+
+			for _, key := range []int{-1, 0, 1, n / 2, n + 1, 2*n - 2, 2 * n, rand.Intn(2*n + 1)} {
+				left, right := tree.SplitAt(key)
+				verifyBTreeProperties(t, left, left.Root, degree, true)
+				verifyBTreeProperties(t, right, right.Root, degree, true)
+				for _, part := range []*BeeTree[int, int]{left, right} {
+					if err := part.Verify(); err != nil {
+						t.Fatalf("Degree %d, %d keys: Verify failed after split at %d: %v", degree, n, key, err)
+					}
+				}
+
+				expectedLeft, expectedRight := map[int]int{}, map[int]int{}
+				for k, v := range before {
+					if k < key {
+						expectedLeft[k] = v
+					} else {
+						expectedRight[k] = v
+					}
+				}
+				if !reflect.DeepEqual(collectPairs(left), expectedLeft) || left.Len() != len(expectedLeft) {
+					t.Fatalf("Degree %d, %d keys: unexpected left tree split at %d", degree, n, key)
+				}
+				if !reflect.DeepEqual(collectPairs(right), expectedRight) || right.Len() != len(expectedRight) {
+					t.Fatalf("Degree %d, %d keys: unexpected right tree split at %d", degree, n, key)
+				}
+
+				joined, err := Join(left, right)
+				if err != nil {
+					t.Fatalf("Degree %d, %d keys: Join failed after split at %d: %v", degree, n, key, err)
+				}
+				verifyBTreeProperties(t, joined, joined.Root, degree, true)
+				if err := joined.Verify(); err != nil {
+					t.Fatalf("Degree %d, %d keys: Verify failed after join: %v", degree, n, err)
+				}
+				if !reflect.DeepEqual(collectPairs(joined), before) {
+					t.Fatalf("Degree %d, %d keys: split at %d and join lost keys", degree, n, key)
+				}
+
+				// The parts are independent of the tree and of each other.
+				left.Put(key, -1)
+				right.DeleteMin()
+				joined.Delete(key + 2)
+			}
+
+			if !reflect.DeepEqual(collectPairs(tree), before) {
+				t.Fatalf("Degree %d, %d keys: split modified the tree", degree, n)
+			}
+			verifyBTreeProperties(t, tree, tree.Root, degree, true)
+		}
+	}
+}
+
+// TestSplitAtSharesNodes tests that a split only creates new nodes along the path to the key
+func TestSplitAtSharesNodes(t *testing.T) {
+	tree := NewBeetree[int, int](3)
+	tree.InsertBatch(perm(100000))
+	original := nodeSet(tree.Root, map[*Node[int, int]]bool{})
+
+	left, right := tree.SplitAt(54321)
+	created := 0
+	for _, part := range []*BeeTree[int, int]{left, right} {
+		for node := range nodeSet(part.Root, map[*Node[int, int]]bool{}) {
+			if !original[node] {
+				created++
+			}
+		}
+	}
+	if limit := 4 * tree.Root.height(); created > limit {
+		t.Errorf("Split created %d nodes, expected at most %d", created, limit)
+	}
+}
+
+// TestJoin tests joining trees of very different heights in both directions
+func TestJoin(t *testing.T) {
+	for degree := 2; degree <= 5; degree++ {
+		for round := 0; round < 50; round++ {
+			small, large := rand.Intn(50), rand.Intn(5000)
+			left, right := NewBeetree[int, int](degree), NewBeetree[int, int](degree)
+			expected := map[int]int{}
+			if round%2 == 0 {
+				small, large = large, small
+			}
+			for i := 0; i < small; i++ {
+				left.Put(i, i)
+				expected[i] = i
+			}
+			for i := small; i < small+large; i++ {
+				right.Put(i, -i)
+				expected[i] = -i
+			}
+			leftBefore, rightBefore := collectPairs(left), collectPairs(right)
+
+			joined, err := Join(left, right)
+			if err != nil {
+				t.Fatalf("Degree %d, round %d: %v", degree, round, err)
+			}
+			verifyBTreeProperties(t, joined, joined.Root, degree, true)
+			if err := joined.Verify(); err != nil {
+				t.Fatalf("Degree %d, round %d: Verify failed: %v", degree, round, err)
+			}
+			if !reflect.DeepEqual(collectPairs(joined), expected) || joined.Len() != len(expected) {
+				t.Fatalf("Degree %d, round %d: joined tree has unexpected keys", degree, round)
+			}
+			if !reflect.DeepEqual(collectPairs(left), leftBefore) || !reflect.DeepEqual(collectPairs(right), rightBefore) {
+				t.Fatalf("Degree %d, round %d: Join modified its input trees", degree, round)
+			}
+
+			joined.Put(-1, 1)
+			if left.Has(-1) || right.Has(-1) {
+				t.Fatalf("Degree %d, round %d: joined tree shares writes with its input trees", degree, round)
+			}
+		}
+	}
+}
+
+// TestJoinRejectsInvalidTrees tests that Join refuses overlapping keys and different degrees
+func TestJoinRejectsInvalidTrees(t *testing.T) {
+	left, right := NewBeetree[int, int](3), NewBeetree[int, int](3)
+	left.InsertBatch(perm(100))
+	right.InsertBatch([]Key[int, int]{{K: 99, V: 0}, {K: 200, V: 0}})
+	if _, err := Join(left, right); !errors.Is(err, ErrJoinOrder) {
+		t.Errorf("Expected ErrJoinOrder for overlapping trees, got %v", err)
+	}
+
+	if _, err := Join(left, NewBeetree[int, int](2)); err == nil {
+		t.Errorf("Expected an error for trees of different degrees")
+	}
+}
@@ -0,0 +1,161 @@
+package beetree
+
+import (
+	"cmp"
+	"iter"
+	"sync"
+)
+
+// SyncTree is a BeeTree that is safe for concurrent use by multiple goroutines.
+//
+// Reads share a read lock and writes take an exclusive lock, so every operation
+// is linearizable. Range scans iterate over a snapshot of the btree taken with
+// Clone, so they see a consistent state without blocking writers while the
+// iterator runs.
+type SyncTree[K, V any] struct {
+	mu   sync.RWMutex
+	tree *BeeTree[K, V]
+}
+
+// NewSyncTree creates a SyncTree of the given degree for keys with a natural
+// ordering.
+func NewSyncTree[K cmp.Ordered, V any](degree int) *SyncTree[K, V] {
+	return &SyncTree[K, V]{tree: NewBeetree[K, V](degree)}
+}
+
+// NewSyncTreeFunc creates a SyncTree of the given degree whose keys are ordered
+// by compare.
+func NewSyncTreeFunc[K, V any](degree int, compare func(a, b K) int) *SyncTree[K, V] {
+	return &SyncTree[K, V]{tree: NewBeetreeFunc[K, V](degree, compare)}
+}
+
+// Put adds the key with the given value to the btree. If the key already exists,
+// its value is replaced and the previous value is returned together with true.
+func (st *SyncTree[K, V]) Put(key K, value V) (V, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.tree.Put(key, value)
+}
+
+// Get returns the value stored for the key. The second value reports whether
+// the key was found.
+func (st *SyncTree[K, V]) Get(key K) (V, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.tree.Get(key)
+}
+
+// Has reports whether the key is stored in the btree.
+func (st *SyncTree[K, V]) Has(key K) bool {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.tree.Has(key)
+}
+
+// Delete deletes a key from the btree if found and returns its value. The second
+// value reports whether the key was found.
+func (st *SyncTree[K, V]) Delete(key K) (V, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.tree.Delete(key)
+}
+
+// Len returns the number of keys stored in the btree.
+func (st *SyncTree[K, V]) Len() int {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.tree.Len()
+}
+
+// Min returns the smallest key of the btree and its value. The last value
+// reports whether the btree has keys.
+func (st *SyncTree[K, V]) Min() (K, V, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.tree.Min()
+}
+
+// Max returns the largest key of the btree and its value. The last value
+// reports whether the btree has keys.
+func (st *SyncTree[K, V]) Max() (K, V, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.tree.Max()
+}
+
+// DeleteMin deletes the smallest key of the btree and returns it with its value.
+// The last value reports whether the btree had keys.
+func (st *SyncTree[K, V]) DeleteMin() (K, V, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.tree.DeleteMin()
+}
+
+// DeleteMax deletes the largest key of the btree and returns it with its value.
+// The last value reports whether the btree had keys.
+func (st *SyncTree[K, V]) DeleteMax() (K, V, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.tree.DeleteMax()
+}
+
+// Snapshot returns a copy of the btree as it is at the time of the call. The
+// copy is a lazy Clone, so it is cheap to take, and it is not affected by later
+// writes to the SyncTree.
+//
+// The snapshot itself is not safe for concurrent writes.
+func (st *SyncTree[K, V]) Snapshot() *BeeTree[K, V] {
+	// Clone changes the copy on write context of the btree, so it is a write.
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.tree.Clone()
+}
+
+// Ascend calls the iterator for every key in a snapshot of the btree within the
+// range [first, last], until iterator returns false.
+func (st *SyncTree[K, V]) Ascend(iterator ItemIterator[K, V]) {
+	st.Snapshot().Ascend(iterator)
+}
+
+// AscendRange calls the iterator for every key in a snapshot of the btree within
+// the range [greaterOrEqual, lessThan), until iterator returns false.
+func (st *SyncTree[K, V]) AscendRange(greaterOrEqual, lessThan K, iterator ItemIterator[K, V]) {
+	st.Snapshot().AscendRange(greaterOrEqual, lessThan, iterator)
+}
+
+// Descend calls the iterator for every key in a snapshot of the btree within the
+// range [last, first], until iterator returns false.
+func (st *SyncTree[K, V]) Descend(iterator ItemIterator[K, V]) {
+	st.Snapshot().Descend(iterator)
+}
+
+// DescendRange calls the iterator for every key in a snapshot of the btree within
+// the range [lessOrEqual, greaterThan), until iterator returns false.
+func (st *SyncTree[K, V]) DescendRange(lessOrEqual, greaterThan K, iterator ItemIterator[K, V]) {
+	st.Snapshot().DescendRange(lessOrEqual, greaterThan, iterator)
+}
+
+// All returns an iterator over all the keys and values of a snapshot of the
+// btree in ascending order. The snapshot is taken when the iteration starts.
+func (st *SyncTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		st.Snapshot().Ascend(yield)
+	}
+}
+
+// Backward returns an iterator over all the keys and values of a snapshot of the
+// btree in descending order. The snapshot is taken when the iteration starts.
+func (st *SyncTree[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		st.Snapshot().Descend(yield)
+	}
+}
+
+// Range returns an iterator over the keys and values of a snapshot of the btree
+// within the range [greaterOrEqual, lessThan) in ascending order. The snapshot is
+// taken when the iteration starts.
+func (st *SyncTree[K, V]) Range(greaterOrEqual, lessThan K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		st.Snapshot().AscendRange(greaterOrEqual, lessThan, yield)
+	}
+}
@@ -0,0 +1,126 @@
+package beetree
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// TestSyncTreeOperations tests the SyncTree operations from a single goroutine
+func TestSyncTreeOperations(t *testing.T) {
+	tree := NewSyncTree[int, int](2)
+	for _, k := range rand.Perm(100) {
+		tree.Put(k, k*10)
+	}
+
+	if tree.Len() != 100 {
+		t.Errorf("Expected length 100, got %d", tree.Len())
+	}
+	if v, found := tree.Get(42); !found || v != 420 {
+		t.Errorf("Expected value 420 for key 42, got %d (found %t)", v, found)
+	}
+	if v, found := tree.Delete(42); !found || v != 420 || tree.Has(42) {
+		t.Errorf("Expected key 42 to be deleted, got %d (found %t)", v, found)
+	}
+	if k, _, _ := tree.DeleteMin(); k != 0 {
+		t.Errorf("Expected min 0, got %d", k)
+	}
+	if k, _, _ := tree.DeleteMax(); k != 99 {
+		t.Errorf("Expected max 99, got %d", k)
+	}
+	if k, _, _ := tree.Min(); k != 1 {
+		t.Errorf("Expected min 1, got %d", k)
+	}
+	if k, _, _ := tree.Max(); k != 98 {
+		t.Errorf("Expected max 98, got %d", k)
+	}
+
+	// Writing while iterating does not deadlock and is not seen by the iteration.
+	count := 0
+	for k := range tree.All() {
+		tree.Delete(k)
+		count++
+	}
+	if count != 97 || tree.Len() != 0 {
+		t.Errorf("Expected to iterate over 97 keys and delete them all, got %d keys and length %d", count, tree.Len())
+	}
+}
+
+// TestSyncTreeConcurrentStress tests concurrent writers and readers and then checks the tree invariants.
+// Run it with -race to detect unsynchronized accesses.
+func TestSyncTreeConcurrentStress(t *testing.T) {
+	const writers, readers, keysPerWriter = 4, 4, 2000
+
+	tree := NewSyncTree[int, int](3)
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			// Every writer owns the keys congruent to w, inserts all of them and
+			// then deletes the odd ones.
+			for i := 0; i < keysPerWriter; i++ {
+				tree.Put(i*writers+w, w)
+			}
+			for i := 1; i < keysPerWriter; i += 2 {
+				if _, found := tree.Delete(i*writers + w); !found {
+					t.Errorf("writer %d: key %d not found", w, i*writers+w)
+					return
+				}
+			}
+		}(w)
+	}
+
+	done := make(chan struct{})
+	var readersWg sync.WaitGroup
+	for r := 0; r < readers; r++ {
+		readersWg.Add(1)
+		go func() {
+			defer readersWg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				// Range scans must always see keys in strictly ascending order.
+				previous := -1
+				for k, v := range tree.Range(0, writers*keysPerWriter) {
+					if k <= previous {
+						t.Errorf("range scan out of order: %d after %d", k, previous)
+						return
+					}
+					if v != k%writers {
+						t.Errorf("key %d has value %d", k, v)
+						return
+					}
+					previous = k
+				}
+
+				k := rand.Intn(writers * keysPerWriter)
+				if v, found := tree.Get(k); found && v != k%writers {
+					t.Errorf("key %d has value %d", k, v)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	readersWg.Wait()
+
+	snapshot := tree.Snapshot()
+	verifyBTreeProperties(t, snapshot, snapshot.Root, snapshot.Degree, true)
+
+	if want := writers * keysPerWriter / 2; tree.Len() != want {
+		t.Errorf("Expected length %d, got %d", want, tree.Len())
+	}
+	for k := range snapshot.All() {
+		if (k/writers)%2 != 0 {
+			t.Errorf("Expected key %d to be deleted", k)
+		}
+	}
+}
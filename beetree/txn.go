@@ -0,0 +1,263 @@
+package beetree
+
+import (
+	"cmp"
+	"errors"
+	"iter"
+	"sync"
+)
+
+var (
+	// ErrConflict is returned by Commit when a key written by the transaction was
+	// committed by another transaction after the transaction began.
+	ErrConflict = errors.New("beetree: transaction conflicts with a concurrent commit")
+
+	// ErrTxnDone is returned by Commit and Rollback when the transaction has
+	// already been committed or rolled back.
+	ErrTxnDone = errors.New("beetree: transaction has already been committed or rolled back")
+)
+
+// TxnTree is a btree that supports multi-key transactions with snapshot isolation.
+// It is safe for concurrent use by multiple goroutines.
+//
+// Every transaction reads from a copy-on-write clone of the committed btree taken
+// when it begins, so it never sees writes that are not committed or that are
+// committed after it began. Writes are buffered in the transaction and applied
+// atomically on commit. A transaction fails to commit if another transaction has
+// committed a write to any of the keys it writes after it began (first committer
+// wins), which prevents lost updates.
+type TxnTree[K, V any] struct {
+	mu        sync.Mutex
+	committed *BeeTree[K, V]
+	// versions stores the version of the last commit that wrote each key, deleted
+	// keys included, while an active transaction began before that commit.
+	versions *BeeTree[K, uint64]
+	// written stores the keys of versions by the version of the commit that wrote
+	// them, so they are forgotten in the order they were committed.
+	written *BeeTree[uint64, []K]
+	// active counts the active transactions by read version.
+	active  *BeeTree[uint64, int]
+	version uint64
+}
+
+// Txn is a transaction of a TxnTree. A Txn must not be used concurrently by
+// multiple goroutines and must end with Commit or Rollback.
+type Txn[K, V any] struct {
+	tree        *TxnTree[K, V]
+	snapshot    *BeeTree[K, V]
+	readVersion uint64
+	writes      *BeeTree[K, txnWrite[V]]
+	done        bool
+}
+
+// txnWrite is a write buffered in a transaction.
+type txnWrite[V any] struct {
+	value   V
+	deleted bool
+}
+
+// NewTxnTree creates a TxnTree of the given degree for keys with a natural ordering.
+func NewTxnTree[K cmp.Ordered, V any](degree int) *TxnTree[K, V] {
+	return NewTxnTreeFunc[K, V](degree, cmp.Compare[K])
+}
+
+// NewTxnTreeFunc creates a TxnTree of the given degree whose keys are ordered by
+// compare.
+func NewTxnTreeFunc[K, V any](degree int, compare func(a, b K) int) *TxnTree[K, V] {
+	return &TxnTree[K, V]{
+		committed: NewBeetreeFunc[K, V](degree, compare),
+		versions:  NewBeetreeFunc[K, uint64](degree, compare),
+		written:   NewBeetree[uint64, []K](degree),
+		active:    NewBeetree[uint64, int](degree),
+	}
+}
+
+// Len returns the number of committed keys.
+func (tt *TxnTree[K, V]) Len() int {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	return tt.committed.Len()
+}
+
+// Begin starts a transaction that sees the keys committed so far.
+func (tt *TxnTree[K, V]) Begin() *Txn[K, V] {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	count, _ := tt.active.Get(tt.version)
+	tt.active.Put(tt.version, count+1)
+	return &Txn[K, V]{
+		tree:        tt,
+		snapshot:    tt.committed.Clone(),
+		readVersion: tt.version,
+		writes:      NewBeetreeFunc[K, txnWrite[V]](tt.committed.Degree, tt.committed.compare),
+	}
+}
+
+// Get returns the value the transaction sees for the key, its own writes
+// included. The second value reports whether the key was found.
+func (tx *Txn[K, V]) Get(key K) (V, bool) {
+	tx.checkDone()
+
+	if w, found := tx.writes.Get(key); found {
+		return w.value, !w.deleted
+	}
+	return tx.snapshot.Get(key)
+}
+
+// Put writes the key with the given value in the transaction. If the transaction
+// sees the key, the previous value is returned together with true.
+func (tx *Txn[K, V]) Put(key K, value V) (V, bool) {
+	old, found := tx.Get(key)
+	tx.writes.Put(key, txnWrite[V]{value: value})
+	return old, found
+}
+
+// Delete deletes the key in the transaction. If the transaction sees the key, its
+// value is returned together with true.
+func (tx *Txn[K, V]) Delete(key K) (V, bool) {
+	old, found := tx.Get(key)
+	if found {
+		tx.writes.Put(key, txnWrite[V]{deleted: true})
+	}
+	return old, found
+}
+
+// Range returns an iterator over the keys and values the transaction sees within
+// the range [greaterOrEqual, lessThan) in ascending order. The transaction must
+// not be written while iterating.
+func (tx *Txn[K, V]) Range(greaterOrEqual, lessThan K) iter.Seq2[K, V] {
+	tx.checkDone()
+
+	return func(yield func(K, V) bool) {
+		compare := tx.snapshot.compare
+
+		// The keys of the snapshot and the writes of the transaction are merged,
+		// the writes win when a key is in both.
+		snapshot, writes := tx.snapshot.Cursor(), tx.writes.Cursor()
+		snapshot.Seek(greaterOrEqual)
+		writes.Seek(greaterOrEqual)
+
+		for {
+			inSnapshot := snapshot.Valid() && compare(snapshot.Key(), lessThan) < 0
+			inWrites := writes.Valid() && compare(writes.Key(), lessThan) < 0
+			if !inSnapshot && !inWrites {
+				return
+			}
+
+			var c int
+			switch {
+			case !inWrites:
+				c = -1
+			case !inSnapshot:
+				c = 1
+			default:
+				c = compare(snapshot.Key(), writes.Key())
+			}
+
+			if c < 0 {
+				if !yield(snapshot.Key(), snapshot.Value()) {
+					return
+				}
+				snapshot.Next()
+				continue
+			}
+
+			if c == 0 {
+				snapshot.Next()
+			}
+			w := writes.Value()
+			if !w.deleted && !yield(writes.Key(), w.value) {
+				return
+			}
+			writes.Next()
+		}
+	}
+}
+
+// Commit applies the writes of the transaction atomically. It returns ErrConflict,
+// and applies none of the writes, if any of the written keys was committed by
+// another transaction after the transaction began.
+func (tx *Txn[K, V]) Commit() error {
+	if tx.done {
+		return ErrTxnDone
+	}
+	tx.done = true
+
+	tt := tx.tree
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	defer tt.end(tx)
+
+	if tx.writes.Len() == 0 {
+		return nil
+	}
+
+	for key := range tx.writes.All() {
+		if version, _ := tt.versions.Get(key); version > tx.readVersion {
+			return ErrConflict
+		}
+	}
+
+	tt.version++
+	keys := make([]K, 0, tx.writes.Len())
+	for key, w := range tx.writes.All() {
+		if w.deleted {
+			tt.committed.Delete(key)
+		} else {
+			tt.committed.Put(key, w.value)
+		}
+		tt.versions.Put(key, tt.version)
+		keys = append(keys, key)
+	}
+	tt.written.Put(tt.version, keys)
+	return nil
+}
+
+// Rollback discards the writes of the transaction.
+func (tx *Txn[K, V]) Rollback() error {
+	if tx.done {
+		return ErrTxnDone
+	}
+	tx.done = true
+
+	tt := tx.tree
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	tt.end(tx)
+	return nil
+}
+
+// end removes the transaction from the active ones. The versions of the keys
+// committed before every active transaction began can't cause a conflict
+// anymore, so they are forgotten. tt.mu must be held.
+func (tt *TxnTree[K, V]) end(tx *Txn[K, V]) {
+	if count, _ := tt.active.Get(tx.readVersion); count > 1 {
+		tt.active.Put(tx.readVersion, count-1)
+	} else {
+		tt.active.Delete(tx.readVersion)
+	}
+
+	oldest, _, anyActive := tt.active.Min()
+	for {
+		version, keys, found := tt.written.Min()
+		if !found || (anyActive && version > oldest) {
+			return
+		}
+		for _, key := range keys {
+			// A later commit may have written the key again.
+			if v, _ := tt.versions.Get(key); v == version {
+				tt.versions.Delete(key)
+			}
+		}
+		tt.written.DeleteMin()
+	}
+}
+
+func (tx *Txn[K, V]) checkDone() {
+	if tx.done {
+		panic("beetree: transaction used after commit or rollback")
+	}
+}
@@ -0,0 +1,272 @@
+package beetree
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// TestTxnCommitAndRollback tests that the writes of a transaction are applied on commit and discarded on rollback
+func TestTxnCommitAndRollback(t *testing.T) {
+	tree := NewTxnTree[int, int](2)
+
+	tx := tree.Begin()
+	for i := 0; i < 10; i++ {
+		tx.Put(i, i*10)
+	}
+	if v, found := tx.Delete(3); !found || v != 30 {
+		t.Errorf("Expected to delete value 30 for key 3, got %d (found %t)", v, found)
+	}
+	if old, replaced := tx.Put(4, 41); !replaced || old != 40 {
+		t.Errorf("Expected to replace value 40 for key 4, got %d (replaced %t)", old, replaced)
+	}
+	if _, found := tx.Get(3); found {
+		t.Errorf("Expected the transaction to see its own deletion of key 3")
+	}
+	if tree.Len() != 0 {
+		t.Errorf("Expected no committed keys before commit, got %d", tree.Len())
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if tree.Len() != 9 {
+		t.Errorf("Expected 9 committed keys, got %d", tree.Len())
+	}
+
+	tx = tree.Begin()
+	tx.Delete(0)
+	tx.Put(100, 100)
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	tx = tree.Begin()
+	if v, found := tx.Get(0); !found || v != 0 {
+		t.Errorf("Expected the rolled back deletion of key 0 to be discarded, got %d (found %t)", v, found)
+	}
+	if _, found := tx.Get(100); found {
+		t.Errorf("Expected the rolled back write of key 100 to be discarded")
+	}
+	if v, _ := tx.Get(4); v != 41 {
+		t.Errorf("Expected committed value 41 for key 4, got %d", v)
+	}
+	tx.Rollback()
+
+	if err := tx.Commit(); !errors.Is(err, ErrTxnDone) {
+		t.Errorf("Expected ErrTxnDone committing a rolled back transaction, got %v", err)
+	}
+	if err := tx.Rollback(); !errors.Is(err, ErrTxnDone) {
+		t.Errorf("Expected ErrTxnDone rolling back a rolled back transaction, got %v", err)
+	}
+}
+
+// TestTxnNoDirtyReads tests that a transaction never sees writes that are not committed,
+// nor writes committed after it began
+func TestTxnNoDirtyReads(t *testing.T) {
+	tree := NewTxnTree[string, int](2)
+
+	setup := tree.Begin()
+	setup.Put("a", 1)
+	setup.Commit()
+
+	writer := tree.Begin()
+	reader := tree.Begin()
+
+	writer.Put("a", 2)
+	writer.Put("b", 2)
+	if v, _ := reader.Get("a"); v != 1 {
+		t.Errorf("Expected value 1 for key a before commit, got %d", v)
+	}
+	if _, found := reader.Get("b"); found {
+		t.Errorf("Expected the uncommitted key b not to be seen")
+	}
+
+	if err := writer.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	// The snapshot of the reader is stable.
+	if v, _ := reader.Get("a"); v != 1 {
+		t.Errorf("Expected value 1 for key a after a later commit, got %d", v)
+	}
+	if _, found := reader.Get("b"); found {
+		t.Errorf("Expected key b committed after the reader began not to be seen")
+	}
+	if err := reader.Commit(); err != nil {
+		t.Errorf("Expected a read only transaction to commit, got %v", err)
+	}
+
+	if v, _ := tree.Begin().Get("a"); v != 2 {
+		t.Errorf("Expected value 2 for key a in a new transaction, got %d", v)
+	}
+}
+
+// TestTxnLostUpdate tests that of two transactions writing the same key only the first one to
+// commit succeeds
+func TestTxnLostUpdate(t *testing.T) {
+	tree := NewTxnTree[string, int](2)
+
+	setup := tree.Begin()
+	setup.Put("counter", 0)
+	setup.Commit()
+
+	tx1, tx2 := tree.Begin(), tree.Begin()
+	v1, _ := tx1.Get("counter")
+	v2, _ := tx2.Get("counter")
+	tx1.Put("counter", v1+1)
+	tx2.Put("counter", v2+1)
+
+	if err := tx1.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if err := tx2.Commit(); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+
+	// Deleting a key also conflicts.
+	tx1, tx2 = tree.Begin(), tree.Begin()
+	tx1.Delete("counter")
+	tx2.Put("counter", 10)
+	if err := tx1.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if err := tx2.Commit(); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+
+	// Transactions writing different keys don't conflict.
+	tx1, tx2 = tree.Begin(), tree.Begin()
+	tx1.Put("x", 1)
+	tx2.Put("y", 1)
+	if err := tx1.Commit(); err != nil {
+		t.Errorf("Commit failed: %v", err)
+	}
+	if err := tx2.Commit(); err != nil {
+		t.Errorf("Commit failed: %v", err)
+	}
+}
+
+// TestTxnConcurrentIncrements tests that concurrent read-modify-write transactions retried on
+// conflict never lose an update
+func TestTxnConcurrentIncrements(t *testing.T) {
+	const goroutines, increments = 8, 200
+
+	tree := NewTxnTree[int, int](3)
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < increments; i++ {
+				for {
+					tx := tree.Begin()
+					// Two counters are updated together, they must always be equal.
+					a, _ := tx.Get(0)
+					b, _ := tx.Get(1)
+					if a != b {
+						t.Errorf("Expected both counters to be equal, got %d and %d", a, b)
+					}
+					tx.Put(0, a+1)
+					tx.Put(1, b+1)
+					if err := tx.Commit(); err == nil {
+						break
+					} else if !errors.Is(err, ErrConflict) {
+						t.Errorf("Unexpected error: %v", err)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	tx := tree.Begin()
+	for k := 0; k < 2; k++ {
+		if v, _ := tx.Get(k); v != goroutines*increments {
+			t.Errorf("Expected counter %d to be %d, got %d", k, goroutines*increments, v)
+		}
+	}
+}
+
+// TestTxnRange tests that Range merges the snapshot with the writes of the transaction
+func TestTxnRange(t *testing.T) {
+	tree := NewTxnTree[int, int](2)
+
+	setup := tree.Begin()
+	for i := 0; i < 20; i += 2 {
+		setup.Put(i, i)
+	}
+	setup.Commit()
+
+	tx := tree.Begin()
+	tx.Put(5, 5)
+	tx.Put(6, 60)
+	tx.Delete(8)
+	tx.Put(11, 11)
+	tx.Delete(11)
+	tx.Put(30, 30)
+
+	var keys, values []int
+	for k, v := range tx.Range(3, 14) {
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	if expected := []int{4, 5, 6, 10, 12}; !slices.Equal(keys, expected) {
+		t.Errorf("Expected keys %v, got %v", expected, keys)
+	}
+	if expected := []int{4, 5, 60, 10, 12}; !slices.Equal(values, expected) {
+		t.Errorf("Expected values %v, got %v", expected, values)
+	}
+
+	keys = nil
+	for k := range tx.Range(15, 100) {
+		keys = append(keys, k)
+		if k == 18 {
+			break
+		}
+	}
+	if expected := []int{16, 18}; !slices.Equal(keys, expected) {
+		t.Errorf("Expected keys %v, got %v", expected, keys)
+	}
+}
+
+// TestTxnForgetsOldVersions tests that the commit versions of the keys are only kept while an
+// active transaction can conflict with them
+func TestTxnForgetsOldVersions(t *testing.T) {
+	tree := NewTxnTree[int, int](2)
+	for i := 0; i < 100; i++ {
+		tx := tree.Begin()
+		tx.Put(i, i)
+		tx.Delete(i - 1)
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("Commit %d failed: %v", i, err)
+		}
+	}
+	if n := tree.versions.Len(); n != 0 {
+		t.Errorf("Expected no versions without active transactions, got %d", n)
+	}
+
+	old := tree.Begin()
+	for i := 0; i < 10; i++ {
+		tx := tree.Begin()
+		tx.Put(i, -i)
+		tx.Commit()
+	}
+	if n := tree.versions.Len(); n != 10 {
+		t.Errorf("Expected the versions of the 10 keys committed after the active transaction began, got %d", n)
+	}
+
+	// The versions still detect a conflict with the old transaction.
+	old.Put(5, 5)
+	if err := old.Commit(); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+	if n := tree.versions.Len(); n != 0 {
+		t.Errorf("Expected no versions once the old transaction ended, got %d", n)
+	}
+	if n := tree.written.Len(); n != 0 {
+		t.Errorf("Expected no written keys once the old transaction ended, got %d", n)
+	}
+}
@@ -0,0 +1,143 @@
+package beetree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The rules of a btree checked by Verify.
+const (
+	// RuleKeyOrder requires the keys of a node to be in ascending order and
+	// between the keys of the parent node around it.
+	RuleKeyOrder = "key order"
+	// RuleMinKeys requires every node but the root to have at least Degree-1
+	// keys, and the root to have keys unless the btree is empty.
+	RuleMinKeys = "min keys"
+	// RuleMaxKeys requires every node to have at most 2*Degree-1 keys.
+	RuleMaxKeys = "max keys"
+	// RuleChildren requires every node to be a leaf or to have one child more
+	// than keys.
+	RuleChildren = "children"
+	// RuleLeafDepth requires all the leaves to be at the same depth.
+	RuleLeafDepth = "leaf depth"
+	// RuleSize requires the number of keys of every subtree, and of the btree, to
+	// match the number of keys stored in it.
+	RuleSize = "size"
+)
+
+// VerifyError is returned by Verify for a node that breaks a rule of the btree.
+type VerifyError struct {
+	// Path is the index of the child followed at every level to get from the root
+	// to the node. It is empty for the root.
+	Path []int
+	// Rule is the broken rule, one of the Rule constants.
+	Rule string
+	// Detail describes how the node breaks the rule.
+	Detail string
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("beetree: node %s breaks rule %q: %s", formatPath(e.Path), e.Rule, e.Detail)
+}
+
+// formatPath formats the path of a node as the indexes of the children after
+// root, like root/1/0.
+func formatPath(path []int) string {
+	var b strings.Builder
+	b.WriteString("root")
+	for _, i := range path {
+		b.WriteByte('/')
+		b.WriteString(strconv.Itoa(i))
+	}
+	return b.String()
+}
+
+// Verify checks that the btree is valid and returns a *VerifyError for the
+// first node that breaks one of its rules. It visits every node of the btree.
+func (bt *BeeTree[K, V]) Verify() error {
+	size := 0
+	if bt.Root != nil {
+		v := verifier[K, V]{bt: bt, leafDepth: -1}
+		var err error
+		if size, err = v.verify(bt.Root, nil, nil, nil); err != nil {
+			return err
+		}
+	}
+
+	if size != bt.length {
+		return &VerifyError{Rule: RuleSize, Detail: fmt.Sprintf("btree has %d keys, Len is %d", size, bt.length)}
+	}
+	return nil
+}
+
+type verifier[K, V any] struct {
+	bt *BeeTree[K, V]
+	// leafDepth is the depth of the first leaf found, which must be the depth of
+	// all the leaves.
+	leafDepth int
+}
+
+// verify checks the subtree of the node at path and returns its number of keys.
+// The keys of the subtree must be greater than lo and less than hi when they
+// are not nil.
+func (v *verifier[K, V]) verify(node *Node[K, V], path []int, lo, hi *K) (int, error) {
+	bt := v.bt
+	fail := func(rule, format string, args ...any) (int, error) {
+		return 0, &VerifyError{Path: path, Rule: rule, Detail: fmt.Sprintf(format, args...)}
+	}
+
+	switch {
+	case len(node.Keys) > 2*bt.Degree-1:
+		return fail(RuleMaxKeys, "%d keys, at most %d allowed", len(node.Keys), 2*bt.Degree-1)
+	case len(path) > 0 && len(node.Keys) < bt.Degree-1:
+		return fail(RuleMinKeys, "%d keys, at least %d required", len(node.Keys), bt.Degree-1)
+	case len(path) == 0 && len(node.Keys) == 0 && len(node.Children) > 0:
+		return fail(RuleMinKeys, "root has children but no keys")
+	case len(node.Children) > 0 && len(node.Children) != len(node.Keys)+1:
+		return fail(RuleChildren, "%d children for %d keys", len(node.Children), len(node.Keys))
+	}
+
+	for i, k := range node.Keys {
+		if i > 0 && bt.compare(k.K, node.Keys[i-1].K) <= 0 {
+			return fail(RuleKeyOrder, "key %d (%v) is not greater than the previous key (%v)", i, k.K, node.Keys[i-1].K)
+		}
+		if (lo != nil && bt.compare(k.K, *lo) <= 0) || (hi != nil && bt.compare(k.K, *hi) >= 0) {
+			return fail(RuleKeyOrder, "key %d (%v) is out of the range of the parent keys", i, k.K)
+		}
+	}
+
+	if len(node.Children) == 0 {
+		if v.leafDepth == -1 {
+			v.leafDepth = len(path)
+		}
+		if len(path) != v.leafDepth {
+			return fail(RuleLeafDepth, "leaf at depth %d, the first leaf is at depth %d", len(path), v.leafDepth)
+		}
+	}
+
+	size := len(node.Keys)
+	for i, child := range node.Children {
+		childLo, childHi := lo, hi
+		if i > 0 {
+			childLo = &node.Keys[i-1].K
+		}
+		if i < len(node.Keys) {
+			childHi = &node.Keys[i].K
+		}
+
+		if child == nil {
+			return fail(RuleChildren, "child %d is nil", i)
+		}
+		childSize, err := v.verify(child, append(path[:len(path):len(path)], i), childLo, childHi)
+		if err != nil {
+			return 0, err
+		}
+		size += childSize
+	}
+
+	if node.size != size {
+		return fail(RuleSize, "size is %d, the subtree has %d keys", node.size, size)
+	}
+	return size, nil
+}
@@ -0,0 +1,163 @@
+package beetree
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// TestVerifyValidTrees tests that Verify accepts the trees left by random inserts and deletes, and by
+// writes to clones
+func TestVerifyValidTrees(t *testing.T) {
+	tree := NewBeetree[int, int](3)
+	if err := tree.Verify(); err != nil {
+		t.Fatalf("Verify failed on an empty tree: %v", err)
+	}
+
+	var clones []*BeeTree[int, int]
+	for i := 0; i < 5000; i++ {
+		k := rand.Intn(1000)
+		if rand.Intn(3) == 0 {
+			tree.Delete(k)
+		} else {
+			tree.Put(k, k)
+		}
+
+		if i%500 == 0 {
+			clones = append(clones, tree.Clone())
+		}
+		if err := tree.Verify(); err != nil {
+			t.Fatalf("Verify failed after %d operations: %v", i+1, err)
+		}
+	}
+
+	for _, clone := range clones {
+		if err := clone.Verify(); err != nil {
+			t.Fatalf("Verify failed on a clone: %v", err)
+		}
+	}
+
+	for k := 0; k < 1000; k++ {
+		tree.Delete(k)
+	}
+	if err := tree.Verify(); err != nil {
+		t.Fatalf("Verify failed on a tree emptied by deletes: %v", err)
+	}
+}
+
+// TestVerifyBuiltAndLoadedTrees tests that Verify accepts the trees built from sorted keys and the
+// trees loaded from their binary, JSON and layout encodings
+func TestVerifyBuiltAndLoadedTrees(t *testing.T) {
+	for degree := 2; degree <= 5; degree++ {
+		for _, n := range []int{0, 1, 10, 1000} {
+			tree := NewBeetree[int, int](degree)
+			tree.Root = tree.build(sortedKeys(n), 2*degree-1)
+			tree.length = n
+			if err := tree.Verify(); err != nil {
+				t.Fatalf("Degree %d, %d keys: Verify failed on a built tree: %v", degree, n, err)
+			}
+
+			data, err := tree.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary failed: %v", err)
+			}
+			fromBinary := NewBeetree[int, int](2)
+			if err := fromBinary.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary failed: %v", err)
+			}
+
+			data, err = json.Marshal(tree)
+			if err != nil {
+				t.Fatalf("MarshalJSON failed: %v", err)
+			}
+			fromJSON := NewBeetree[int, int](degree)
+			if err := json.Unmarshal(data, fromJSON); err != nil {
+				t.Fatalf("UnmarshalJSON failed: %v", err)
+			}
+
+			fromLayout, err := NewBeetreeFromLayout(tree.Layout())
+			if err != nil {
+				t.Fatalf("NewBeetreeFromLayout failed: %v", err)
+			}
+
+			for name, loaded := range map[string]*BeeTree[int, int]{"binary": fromBinary, "JSON": fromJSON, "layout": fromLayout} {
+				if err := loaded.Verify(); err != nil {
+					t.Fatalf("Degree %d, %d keys: Verify failed on a tree loaded from its %s encoding: %v", degree, n, name, err)
+				}
+			}
+		}
+	}
+}
+
+// TestVerifyReportsBrokenRules tests that Verify reports the node and the rule broken by a damaged
+// tree
+func TestVerifyReportsBrokenRules(t *testing.T) {
+	layout := `{"degree": 2, "root": {"level": 0, "keys": [{"key": 20}, {"key": 40}], "children": [
+		{"level": 1, "keys": [{"key": 5}, {"key": 10}]},
+		{"level": 1, "keys": [{"key": 25}]},
+		{"level": 1, "keys": [{"key": 45}, {"key": 50}]}
+	]}}`
+	leaf := func(tree *BeeTree[int, int], keys ...int) *Node[int, int] {
+		node := tree.newNode()
+		for _, k := range keys {
+			node.Keys = append(node.Keys, Key[int, int]{K: k})
+		}
+		node.size = len(keys)
+		return node
+	}
+
+	cases := []struct {
+		name   string
+		damage func(tree *BeeTree[int, int])
+		path   []int
+		rule   string
+	}{
+		{"unsorted", func(tree *BeeTree[int, int]) {
+			keys := tree.Root.Children[0].Keys
+			keys[0], keys[1] = keys[1], keys[0]
+		}, []int{0}, RuleKeyOrder},
+		{"out of range", func(tree *BeeTree[int, int]) {
+			tree.Root.Children[2].Keys[0].K = 30
+		}, []int{2}, RuleKeyOrder},
+		{"too few keys", func(tree *BeeTree[int, int]) {
+			tree.Root.Children[1].Keys = nil
+		}, []int{1}, RuleMinKeys},
+		{"too many keys", func(tree *BeeTree[int, int]) {
+			tree.Root.Children[2] = leaf(tree, 45, 50, 60, 70)
+		}, []int{2}, RuleMaxKeys},
+		{"missing child", func(tree *BeeTree[int, int]) {
+			tree.Root.Children = tree.Root.Children[:2]
+		}, nil, RuleChildren},
+		{"leaf depth", func(tree *BeeTree[int, int]) {
+			inner := leaf(tree, 25)
+			inner.Children = append(inner.Children, leaf(tree, 22), leaf(tree, 27))
+			inner.size = 3
+			tree.Root.Children[1] = inner
+		}, []int{1, 0}, RuleLeafDepth},
+		{"subtree size", func(tree *BeeTree[int, int]) {
+			tree.Root.Children[2].size++
+		}, []int{2}, RuleSize},
+		{"length", func(tree *BeeTree[int, int]) {
+			tree.length++
+		}, nil, RuleSize},
+	}
+
+	for _, c := range cases {
+		tree, err := NewBeetreeFromLayout(mustLayout(t, layout))
+		if err != nil {
+			t.Fatalf("NewBeetreeFromLayout failed: %v", err)
+		}
+		c.damage(tree)
+
+		var verr *VerifyError
+		if err := tree.Verify(); !errors.As(err, &verr) {
+			t.Errorf("%s: expected a VerifyError, got %v", c.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(verr.Path, c.path) || verr.Rule != c.rule {
+			t.Errorf("%s: expected rule %q broken at %v, got %v", c.name, c.rule, c.path, verr)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package beetree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// The write-ahead log (WAL) of a DiskTree is a sequence of records stored in a
+// separate file. Every operation that modifies the btree appends the full image
+// of every page it modified, the meta page included, followed by a commit record.
+// Pages are only written to the btree file once the records holding them are in
+// stable storage, so after a crash the file can be brought to the state of the
+// last committed operation by writing again the pages of the committed records.
+//
+// Every record has the following layout, where the checksum covers the rest of
+// the record:
+//
+//	[0]          kind
+//	[1:9]        page id
+//	[9:13]       length of the payload n
+//	[13:13+n]    payload, the page image
+//	[13+n:17+n]  crc32 checksum
+const (
+	walHeaderSize = 13
+
+	walPage   byte = 1
+	walCommit byte = 2
+)
+
+// DefaultCheckpointSize is the size the WAL grows to before a checkpoint, when none
+// is given.
+const DefaultCheckpointSize = 4 << 20
+
+type wal struct {
+	file storageFile
+	// size is the offset where the next record is written.
+	size int64
+	// synced reports whether every record written is in stable storage.
+	synced bool
+	// buf holds the records of the operation in progress.
+	buf []byte
+}
+
+// appendPage buffers the image of a page.
+func (w *wal) appendPage(id pageID, page []byte) {
+	w.buf = appendWALRecord(w.buf, walPage, id, page)
+}
+
+// commit writes the buffered records followed by a commit record. If sync is true
+// the records are flushed to stable storage.
+func (w *wal) commit(sync bool) error {
+	w.buf = appendWALRecord(w.buf, walCommit, 0, nil)
+
+	_, err := w.file.WriteAt(w.buf, w.size)
+	n := len(w.buf)
+	w.buf = w.buf[:0]
+	w.synced = false
+	if err != nil {
+		// The size is left before the torn records, so the next commit overwrites
+		// them. They are also cut off, in case the next commit is shorter.
+		return errors.Join(fmt.Errorf("beetree: writing WAL: %w", err), w.file.Truncate(w.size))
+	}
+	w.size += int64(n)
+
+	if sync {
+		return w.sync()
+	}
+	return nil
+}
+
+// sync flushes the records written to stable storage.
+func (w *wal) sync() error {
+	if w.synced {
+		return nil
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("beetree: syncing WAL: %w", err)
+	}
+	w.synced = true
+	return nil
+}
+
+// reset empties the WAL once its pages are in stable storage in the btree file.
+func (w *wal) reset() error {
+	if err := w.file.Truncate(0); err != nil {
+		return fmt.Errorf("beetree: truncating WAL: %w", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("beetree: syncing WAL: %w", err)
+	}
+	w.size = 0
+	w.synced = true
+	return nil
+}
+
+func appendWALRecord(buf []byte, kind byte, id pageID, payload []byte) []byte {
+	start := len(buf)
+	buf = append(buf, kind)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(id))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(payload)))
+	buf = append(buf, payload...)
+	return binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf[start:]))
+}
+
+// recoverWAL writes to the btree file the pages of every committed operation in
+// the WAL and empties the WAL. Records after the last commit record, or after the
+// first record that is incomplete or fails its checksum, are from an operation
+// that did not commit and are discarded.
+func recoverWAL(w *wal, data storageFile) error {
+	info, err := w.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return nil
+	}
+
+	log := make([]byte, info.Size())
+	if _, err := w.file.ReadAt(log, 0); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("beetree: reading WAL: %w", err)
+	}
+
+	// pages holds the latest committed image of every page, pending the images of
+	// the operation being read.
+	pages := map[pageID][]byte{}
+	var pending []pageID
+	pendingPages := map[pageID][]byte{}
+
+	for len(log) >= walHeaderSize+4 {
+		kind := log[0]
+		id := pageID(binary.LittleEndian.Uint64(log[1:9]))
+		n := int(binary.LittleEndian.Uint32(log[9:13]))
+		if n > len(log)-walHeaderSize-4 {
+			break
+		}
+		end := walHeaderSize + n
+		if binary.LittleEndian.Uint32(log[end:]) != crc32.ChecksumIEEE(log[:end]) {
+			break
+		}
+
+		switch kind {
+		case walPage:
+			if _, ok := pendingPages[id]; !ok {
+				pending = append(pending, id)
+			}
+			pendingPages[id] = log[walHeaderSize:end]
+		case walCommit:
+			for _, id := range pending {
+				pages[id] = pendingPages[id]
+			}
+			pending = pending[:0]
+			clear(pendingPages)
+		default:
+			return fmt.Errorf("%w: unknown WAL record kind %d", ErrCorrupt, kind)
+		}
+
+		log = log[end+4:]
+	}
+
+	for id, page := range pages {
+		if _, err := data.WriteAt(page, int64(id)*int64(len(page))); err != nil {
+			return fmt.Errorf("beetree: recovering page %d: %w", id, err)
+		}
+	}
+	if err := data.Sync(); err != nil {
+		return err
+	}
+
+	return w.reset()
+}
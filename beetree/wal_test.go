@@ -0,0 +1,296 @@
+package beetree
+
+import (
+	"errors"
+	"maps"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var errInjectedFault = errors.New("injected fault")
+
+// faultBudget is the number of bytes the files of a tree can write before they crash. The write
+// that exceeds the budget is cut at a random offset and every later write or sync fails.
+type faultBudget struct {
+	remaining int64
+	written   int64
+	crashed   bool
+}
+
+type faultyFile struct {
+	*os.File
+	budget *faultBudget
+}
+
+func (f *faultyFile) WriteAt(p []byte, off int64) (int, error) {
+	if f.budget.crashed {
+		return 0, errInjectedFault
+	}
+	if int64(len(p)) > f.budget.remaining {
+		n, _ := f.File.WriteAt(p[:f.budget.remaining], off)
+		f.budget.crashed = true
+		return n, errInjectedFault
+	}
+
+	f.budget.remaining -= int64(len(p))
+	f.budget.written += int64(len(p))
+	return f.File.WriteAt(p, off)
+}
+
+func (f *faultyFile) Sync() error {
+	if f.budget.crashed {
+		return errInjectedFault
+	}
+	return f.File.Sync()
+}
+
+func (f *faultyFile) Truncate(size int64) error {
+	if f.budget.crashed {
+		return errInjectedFault
+	}
+	return f.File.Truncate(size)
+}
+
+// crashOptions returns options whose files crash after writing budget bytes
+func crashOptions(budget *faultBudget) *Options {
+	return &Options{
+		PageSize:       128,
+		CacheSize:      minCacheSize,
+		CheckpointSize: 8 << 10,
+		openFile: func(path string) (storageFile, error) {
+			f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+			if err != nil {
+				return nil, err
+			}
+			return &faultyFile{File: f, budget: budget}, nil
+		},
+	}
+}
+
+type crashOp struct {
+	key    int
+	delete bool
+}
+
+// runUntilCrash applies the operations to a tree whose files crash after writing budget bytes. It
+// returns the contents of the tree before and after the operation that crashed, which are equal
+// if no operation crashed.
+func runUntilCrash(t *testing.T, path string, ops []crashOp, budget *faultBudget) (before, after map[int]int) {
+	t.Helper()
+
+	expected := map[int]int{}
+	tree, err := Open[int, int](path, IntCodec{}, IntCodec{}, crashOptions(budget))
+	if err != nil {
+		if !budget.crashed {
+			t.Fatalf("Open failed: %v", err)
+		}
+		return expected, expected
+	}
+
+	for i, op := range ops {
+		before = maps.Clone(expected)
+		if op.delete {
+			delete(expected, op.key)
+			_, _, err = tree.Delete(op.key)
+		} else {
+			expected[op.key] = i
+			_, _, err = tree.Put(op.key, i)
+		}
+
+		if err != nil {
+			if !budget.crashed {
+				t.Fatalf("Operation %d failed: %v", i, err)
+			}
+			// The process dies, the files are closed without a checkpoint.
+			tree.file.file.Close()
+			tree.wal.file.Close()
+			return before, expected
+		}
+	}
+
+	if err := tree.Close(); err != nil {
+		if !budget.crashed {
+			t.Fatalf("Close failed: %v", err)
+		}
+		tree.file.file.Close()
+		tree.wal.file.Close()
+	}
+	return expected, expected
+}
+
+// TestDiskTreeRecoversFromCrashes kills the writes of a tree at random offsets and checks that the
+// recovered tree is valid and holds every operation that returned, plus maybe the one that crashed
+func TestDiskTreeRecoversFromCrashes(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	ops := make([]crashOp, 400)
+	for i := range ops {
+		ops[i] = crashOp{key: r.Intn(150), delete: r.Intn(3) == 0}
+	}
+
+	// A run without faults tells how many bytes the operations write.
+	unlimited := &faultBudget{remaining: math.MaxInt64}
+	runUntilCrash(t, filepath.Join(t.TempDir(), "tree.db"), ops, unlimited)
+
+	runs := 100
+	if testing.Short() {
+		runs = 20
+	}
+	for run := 0; run < runs; run++ {
+		path := filepath.Join(t.TempDir(), "tree.db")
+		budget := &faultBudget{remaining: r.Int63n(unlimited.written)}
+		before, after := runUntilCrash(t, path, ops, budget)
+
+		tree := openIntTree(t, path, &Options{CacheSize: minCacheSize})
+		count := verifyDiskTree(t, tree)
+
+		recovered := map[int]int{}
+		if err := tree.Ascend(func(k, v int) bool {
+			recovered[k] = v
+			return true
+		}); err != nil {
+			t.Fatalf("run %d: Ascend failed: %v", run, err)
+		}
+		if count != tree.Len() || count != len(recovered) {
+			t.Errorf("run %d: Tree has %d keys but length %d", run, count, tree.Len())
+		}
+		if !maps.Equal(recovered, before) && !maps.Equal(recovered, after) {
+			t.Fatalf("run %d: Recovered %d keys, expected the %d keys before the crash or the %d keys after it",
+				run, len(recovered), len(before), len(after))
+		}
+
+		// The recovered tree keeps working.
+		tree.Put(1000, 1000)
+		if err := tree.Close(); err != nil {
+			t.Fatalf("run %d: Close failed: %v", run, err)
+		}
+	}
+}
+
+// TestRecoverWALDiscardsUncommittedRecords tests that records after the last commit record are not
+// written to the btree file
+func TestRecoverWALDiscardsUncommittedRecords(t *testing.T) {
+	dir := t.TempDir()
+	data, _ := os.Create(filepath.Join(dir, "data"))
+	walFile, _ := os.Create(filepath.Join(dir, "wal"))
+	defer data.Close()
+	defer walFile.Close()
+
+	page := func(b byte) []byte {
+		p := make([]byte, 16)
+		for i := range p {
+			p[i] = b
+		}
+		return p
+	}
+
+	w := &wal{file: walFile}
+	w.appendPage(1, page('a'))
+	w.appendPage(2, page('b'))
+	w.commit(true)
+	w.appendPage(1, page('c'))
+	w.commit(true)
+	// The last operation has no commit record.
+	w.buf = appendWALRecord(w.buf, walPage, 0, page('d'))
+	walFile.WriteAt(w.buf, w.size)
+
+	if err := recoverWAL(&wal{file: walFile}, data); err != nil {
+		t.Fatalf("recoverWAL failed: %v", err)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, "data"))
+	expected := string(make([]byte, 16)) + string(page('c')) + string(page('b'))
+	if string(content) != expected {
+		t.Errorf("Unexpected content %q", content)
+	}
+	if info, _ := walFile.Stat(); info.Size() != 0 {
+		t.Errorf("Expected the WAL to be empty after recovery, got %d bytes", info.Size())
+	}
+}
+
+// flakyFile cuts a single write in half and fails it, the writes before and after it work.
+type flakyFile struct {
+	*os.File
+	writes int
+	failAt int
+}
+
+func (f *flakyFile) WriteAt(p []byte, off int64) (int, error) {
+	f.writes++
+	if f.writes == f.failAt {
+		n, _ := f.File.WriteAt(p[:len(p)/2], off)
+		return n, errInjectedFault
+	}
+	return f.File.WriteAt(p, off)
+}
+
+// TestDiskTreeFailsAfterFailedWALWrite tests that a write to the WAL that fails half way makes
+// the btree refuse any other use, and that the failed write is not recovered after reopening it
+func TestDiskTreeFailsAfterFailedWALWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree, err := Open[int, int](path, IntCodec{}, IntCodec{}, &Options{
+		PageSize:       128,
+		CacheSize:      minCacheSize,
+		CheckpointSize: 1 << 30,
+		openFile: func(path string) (storageFile, error) {
+			f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+			if err != nil || !strings.HasSuffix(path, "-wal") {
+				return f, err
+			}
+			return &flakyFile{File: f, failAt: 20}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	failedAt := -1
+	for i := 0; i < 100; i++ {
+		_, _, err := tree.Put(i, i)
+		if err == nil {
+			continue
+		}
+		if failedAt < 0 {
+			if !errors.Is(err, errInjectedFault) || !errors.Is(err, ErrFailed) {
+				t.Fatalf("Put %d failed: %v", i, err)
+			}
+			failedAt = i
+		} else if !errors.Is(err, ErrFailed) {
+			t.Fatalf("Expected ErrFailed for Put %d after a failed Put, got %v", i, err)
+		}
+	}
+	if failedAt < 0 {
+		t.Fatalf("Expected a failed Put")
+	}
+	if _, _, err := tree.Get(0); !errors.Is(err, ErrFailed) {
+		t.Errorf("Expected ErrFailed for Get after a failed Put, got %v", err)
+	}
+	if err := tree.Checkpoint(); !errors.Is(err, ErrFailed) {
+		t.Errorf("Expected ErrFailed for Checkpoint after a failed Put, got %v", err)
+	}
+
+	// Close must not checkpoint the pages of the failed Put.
+	if err := tree.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	tree = openIntTree(t, path, &Options{CacheSize: minCacheSize})
+	defer tree.Close()
+	if count := verifyDiskTree(t, tree); count != failedAt {
+		t.Fatalf("Expected %d keys after recovery, got %d", failedAt, count)
+	}
+	for i := 0; i < failedAt; i++ {
+		if v, found, _ := tree.Get(i); !found || v != i {
+			t.Fatalf("Key %d lost after recovery", i)
+		}
+	}
+	if found, _ := tree.Has(failedAt); found {
+		t.Fatalf("Key %d of the failed Put found after recovery", failedAt)
+	}
+	if _, _, err := tree.Put(failedAt, failedAt); err != nil {
+		t.Fatalf("Put after recovery failed: %v", err)
+	}
+}
@@ -39,6 +39,7 @@ package gbtree
 import (
 	"fmt"
 	"io"
+	"iter"
 	"sort"
 	"strings"
 	"sync"
@@ -332,6 +333,35 @@ func (n *node) get(key Item) Item {
 	return nil
 }
 
+// neighbor finds the closest item to pivot in the subtree with a single descent.
+// If inclusive is true, an item equal to pivot is returned when found. If above
+// is true, the closest greater item is searched, otherwise the closest smaller.
+func (n *node) neighbor(pivot Item, inclusive, above bool) (Item, bool) {
+	var candidate Item
+	for n != nil {
+		i, found := n.items.find(pivot)
+		if found {
+			if inclusive {
+				return n.items[i], true
+			}
+			if above {
+				i++
+			}
+		}
+		if above && i < len(n.items) {
+			candidate = n.items[i]
+		}
+		if !above && i > 0 {
+			candidate = n.items[i-1]
+		}
+		if len(n.children) == 0 {
+			break
+		}
+		n = n.children[i]
+	}
+	return candidate, candidate != nil
+}
+
 // min returns the first item in the subtree.
 func min(n *node) Item {
 	if n == nil {
@@ -807,6 +837,29 @@ func (t *BTree) Descend(iterator ItemIterator) {
 	t.root.iterate(descend, nil, nil, false, false, iterator)
 }
 
+// All returns an iterator over all the items of the tree in ascending order.
+func (t *BTree) All() iter.Seq[Item] {
+	return func(yield func(Item) bool) {
+		t.Ascend(yield)
+	}
+}
+
+// Backward returns an iterator over all the items of the tree in descending
+// order.
+func (t *BTree) Backward() iter.Seq[Item] {
+	return func(yield func(Item) bool) {
+		t.Descend(yield)
+	}
+}
+
+// Range returns an iterator over the items of the tree within the range
+// [greaterOrEqual, lessThan) in ascending order.
+func (t *BTree) Range(greaterOrEqual, lessThan Item) iter.Seq[Item] {
+	return func(yield func(Item) bool) {
+		t.AscendRange(greaterOrEqual, lessThan, yield)
+	}
+}
+
 // Get looks for the key item in the tree, returning it.  It returns nil if
 // unable to find that item.
 func (t *BTree) Get(key Item) Item {
@@ -826,6 +879,30 @@ func (t *BTree) Max() Item {
 	return max(t.root)
 }
 
+// Floor returns the largest item in the tree that is less than or equal to
+// pivot. The second value reports whether such an item exists.
+func (t *BTree) Floor(pivot Item) (Item, bool) {
+	return t.root.neighbor(pivot, true, false)
+}
+
+// Ceiling returns the smallest item in the tree that is greater than or equal
+// to pivot. The second value reports whether such an item exists.
+func (t *BTree) Ceiling(pivot Item) (Item, bool) {
+	return t.root.neighbor(pivot, true, true)
+}
+
+// Lower returns the largest item in the tree that is less than pivot. The
+// second value reports whether such an item exists.
+func (t *BTree) Lower(pivot Item) (Item, bool) {
+	return t.root.neighbor(pivot, false, false)
+}
+
+// Higher returns the smallest item in the tree that is greater than pivot. The
+// second value reports whether such an item exists.
+func (t *BTree) Higher(pivot Item) (Item, bool) {
+	return t.root.neighbor(pivot, false, true)
+}
+
 // Has returns true if the given key is in the tree.
 func (t *BTree) Has(key Item) bool {
 	return t.Get(key) != nil
@@ -836,6 +913,123 @@ func (t *BTree) Len() int {
 	return t.length
 }
 
+// The rules of a B-Tree checked by Verify.
+const (
+	// RuleItemOrder requires the items of a node to be in ascending order and
+	// between the items of the parent node around it.
+	RuleItemOrder = "item order"
+	// RuleMinItems requires every node but the root to have at least degree-1
+	// items, and the root to have items unless the tree is empty.
+	RuleMinItems = "min items"
+	// RuleMaxItems requires every node to have at most 2*degree-1 items.
+	RuleMaxItems = "max items"
+	// RuleChildren requires every node to be a leaf or to have one child more
+	// than items.
+	RuleChildren = "children"
+	// RuleLeafDepth requires all the leaves to be at the same depth.
+	RuleLeafDepth = "leaf depth"
+	// RuleLength requires Len to match the number of items in the tree.
+	RuleLength = "length"
+)
+
+// VerifyError is returned by Verify for a node that breaks a rule of the
+// B-Tree.
+type VerifyError struct {
+	// Path is the index of the child followed at every level to get from the
+	// root to the node.  It is empty for the root.
+	Path []int
+	// Rule is the broken rule, one of the Rule constants.
+	Rule string
+	// Detail describes how the node breaks the rule.
+	Detail string
+}
+
+func (e *VerifyError) Error() string {
+	var b strings.Builder
+	b.WriteString("root")
+	for _, i := range e.Path {
+		fmt.Fprintf(&b, "/%d", i)
+	}
+	return fmt.Sprintf("gbtree: node %s breaks rule %q: %s", b.String(), e.Rule, e.Detail)
+}
+
+// Verify checks that the tree is valid and returns a *VerifyError for the
+// first node that breaks one of its rules.  It visits every node of the tree.
+func (t *BTree) Verify() error {
+	count := 0
+	if t.root != nil {
+		leafDepth := -1
+		var err error
+		if count, err = t.root.verify(t, nil, nil, nil, &leafDepth); err != nil {
+			return err
+		}
+	}
+	if count != t.length {
+		return &VerifyError{Rule: RuleLength, Detail: fmt.Sprintf("tree has %d items, Len is %d", count, t.length)}
+	}
+	return nil
+}
+
+// verify checks the subtree of the node at path and returns its number of
+// items.  The items of the subtree must be greater than lo and less than hi
+// when they are not nil.  leafDepth is the depth of the first leaf found, or -1
+// before any leaf is found.
+func (n *node) verify(t *BTree, path []int, lo, hi Item, leafDepth *int) (int, error) {
+	fail := func(rule, format string, args ...interface{}) (int, error) {
+		return 0, &VerifyError{Path: path, Rule: rule, Detail: fmt.Sprintf(format, args...)}
+	}
+
+	switch {
+	case len(n.items) > t.maxItems():
+		return fail(RuleMaxItems, "%d items, at most %d allowed", len(n.items), t.maxItems())
+	case len(path) > 0 && len(n.items) < t.minItems():
+		return fail(RuleMinItems, "%d items, at least %d required", len(n.items), t.minItems())
+	case len(path) == 0 && len(n.items) == 0 && len(n.children) > 0:
+		return fail(RuleMinItems, "root has children but no items")
+	case len(n.children) > 0 && len(n.children) != len(n.items)+1:
+		return fail(RuleChildren, "%d children for %d items", len(n.children), len(n.items))
+	}
+
+	for i, item := range n.items {
+		if i > 0 && !n.items[i-1].Less(item) {
+			return fail(RuleItemOrder, "item %d (%v) is not greater than the previous item (%v)", i, item, n.items[i-1])
+		}
+		if (lo != nil && !lo.Less(item)) || (hi != nil && !item.Less(hi)) {
+			return fail(RuleItemOrder, "item %d (%v) is out of the range of the parent items", i, item)
+		}
+	}
+
+	if len(n.children) == 0 {
+		if *leafDepth == -1 {
+			*leafDepth = len(path)
+		}
+		if len(path) != *leafDepth {
+			return fail(RuleLeafDepth, "leaf at depth %d, the first leaf is at depth %d", len(path), *leafDepth)
+		}
+	}
+
+	count := len(n.items)
+	for i, child := range n.children {
+		childLo, childHi := lo, hi
+		if i > 0 {
+			childLo = n.items[i-1]
+		}
+		if i < len(n.items) {
+			childHi = n.items[i]
+		}
+
+		if child == nil {
+			return fail(RuleChildren, "child %d is nil", i)
+		}
+		childCount, err := child.verify(t, append(path[:len(path):len(path)], i), childLo, childHi, leafDepth)
+		if err != nil {
+			return 0, err
+		}
+		count += childCount
+	}
+	return count, nil
+}
+
 func (t *BTree) LevelOrderTraversalPrint() {
 	// Empty B Tree.
 	if t.root == nil {
@@ -129,6 +129,74 @@ func TestBTree(t *testing.T) {
 	}
 }
 
+func TestVerifyValidTrees(t *testing.T) {
+	const treeSize = 10000
+	for _, degree := range []int{2, 3, *btreeDegree} {
+		tr := New(degree)
+		for _, item := range perm(treeSize) {
+			tr.ReplaceOrInsert(item)
+		}
+		if err := tr.Verify(); err != nil {
+			t.Fatalf("degree %d: %v", degree, err)
+		}
+		for _, item := range perm(treeSize / 2) {
+			tr.Delete(item)
+		}
+		if err := tr.Verify(); err != nil {
+			t.Fatalf("degree %d: %v", degree, err)
+		}
+		for _, item := range perm(treeSize) {
+			tr.Delete(item)
+		}
+		if err := tr.Verify(); err != nil {
+			t.Fatalf("degree %d: %v", degree, err)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		damage func(tr *BTree)
+		path   []int
+		rule   string
+	}{
+		{"unsorted", func(tr *BTree) {
+			items := tr.root.children[1].items
+			items[0], items[1] = items[1], items[0]
+		}, []int{1}, RuleItemOrder},
+		{"out of range", func(tr *BTree) {
+			tr.root.children[0].items[0] = Int(1000)
+		}, []int{0}, RuleItemOrder},
+		{"too few items", func(tr *BTree) {
+			tr.root.children[0].items = nil
+		}, []int{0}, RuleMinItems},
+		{"too many items", func(tr *BTree) {
+			n := tr.root.children[1]
+			n.items = append(n.items, Int(8), Int(9))
+		}, []int{1}, RuleMaxItems},
+		{"missing child", func(tr *BTree) {
+			tr.root.children.pop()
+		}, nil, RuleChildren},
+		{"length", func(tr *BTree) {
+			tr.length++
+		}, nil, RuleLength},
+	} {
+		tr := New(2)
+		for _, item := range rang(10) {
+			tr.ReplaceOrInsert(item)
+		}
+		if err := tr.Verify(); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		tc.damage(tr)
+		err, ok := tr.Verify().(*VerifyError)
+		if !ok || !reflect.DeepEqual(err.Path, tc.path) || err.Rule != tc.rule {
+			t.Errorf("%s: want rule %q broken at %v, got %v", tc.name, tc.rule, tc.path, err)
+		}
+	}
+}
+
 func ExampleBTree() {
 	tr := New(*btreeDegree)
 	for i := Int(0); i < 10; i++ {
@@ -347,6 +415,125 @@ func TestDescendGreaterThan(t *testing.T) {
 	}
 }
 
+func TestAll(t *testing.T) {
+	tr := New(2)
+	for _, v := range perm(100) {
+		tr.ReplaceOrInsert(v)
+	}
+	var got []Item
+	for item := range tr.All() {
+		got = append(got, item)
+	}
+	if want := rang(100); !reflect.DeepEqual(got, want) {
+		t.Fatalf("all:\n got: %v\nwant: %v", got, want)
+	}
+	got = got[:0]
+	for item := range tr.All() {
+		if item.(Int) > 50 {
+			break
+		}
+		got = append(got, item)
+	}
+	if want := rang(100)[:51]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("all:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestBackward(t *testing.T) {
+	tr := New(2)
+	for _, v := range perm(100) {
+		tr.ReplaceOrInsert(v)
+	}
+	var got []Item
+	for item := range tr.Backward() {
+		got = append(got, item)
+	}
+	if want := rangrev(100); !reflect.DeepEqual(got, want) {
+		t.Fatalf("backward:\n got: %v\nwant: %v", got, want)
+	}
+	got = got[:0]
+	for item := range tr.Backward() {
+		if item.(Int) < 50 {
+			break
+		}
+		got = append(got, item)
+	}
+	if want := rangrev(100)[:50]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("backward:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestRange(t *testing.T) {
+	tr := New(2)
+	for _, v := range perm(100) {
+		tr.ReplaceOrInsert(v)
+	}
+	var got []Item
+	for item := range tr.Range(Int(40), Int(60)) {
+		got = append(got, item)
+	}
+	if want := rang(100)[40:60]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("range:\n got: %v\nwant: %v", got, want)
+	}
+	got = got[:0]
+	for item := range tr.Range(Int(40), Int(60)) {
+		if item.(Int) > 50 {
+			break
+		}
+		got = append(got, item)
+	}
+	if want := rang(100)[40:51]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("range:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+// neighbor returns the item of the sorted list closest to pivot by brute force.
+func neighbor(list []Item, pivot Item, inclusive, above bool) (Item, bool) {
+	var out Item
+	for _, item := range list {
+		equal := !item.Less(pivot) && !pivot.Less(item)
+		if above && (pivot.Less(item) || inclusive && equal) {
+			return item, true
+		}
+		if !above && (item.Less(pivot) || inclusive && equal) {
+			out = item
+		}
+	}
+	return out, out != nil
+}
+
+func TestNeighbors(t *testing.T) {
+	tr := New(2)
+	var list []Item
+	for i := 0; i < 50; i++ {
+		list = append(list, Int(i*2))
+	}
+	for _, i := range rand.Perm(50) {
+		tr.ReplaceOrInsert(list[i])
+	}
+	for pivot := Int(-2); pivot <= 100; pivot++ {
+		for _, tc := range []struct {
+			name             string
+			fn               func(Item) (Item, bool)
+			inclusive, above bool
+		}{
+			{"floor", tr.Floor, true, false},
+			{"ceiling", tr.Ceiling, true, true},
+			{"lower", tr.Lower, false, false},
+			{"higher", tr.Higher, false, true},
+		} {
+			got, ok := tc.fn(pivot)
+			want, wantOk := neighbor(list, pivot, tc.inclusive, tc.above)
+			if ok != wantOk || got != want {
+				t.Fatalf("%s(%v): got %v, %v; want %v, %v", tc.name, pivot, got, ok, want, wantOk)
+			}
+		}
+	}
+	if _, ok := New(2).Floor(Int(1)); ok {
+		t.Fatal("floor on empty tree found an item")
+	}
+}
+
 const benchmarkTreeSize = 10000
 
 func BenchmarkInsert(b *testing.B) {
@@ -7,100 +7,100 @@ import (
 func main() {
 	// BeeTree
 	// startTime := time.Now()
-	bt := beetree.NewBeetree(2)
+	bt := beetree.NewBeetree[int, int](2)
 
 	// 10, 20, 30, 40, 50, 60, 5, 15, 25, 35, 45, 55, 65, 75, 85, 95, 105
-	bt.Insert(beetree.Key{K: 10})
-	bt.Insert(beetree.Key{K: 20})
-	bt.Insert(beetree.Key{K: 30})
-	bt.Insert(beetree.Key{K: 40})
-	bt.Insert(beetree.Key{K: 50})
-	bt.Insert(beetree.Key{K: 60})
-	bt.Insert(beetree.Key{K: 5})
-	bt.Insert(beetree.Key{K: 15})
-	bt.Insert(beetree.Key{K: 25})
-	bt.Insert(beetree.Key{K: 35})
-	bt.Insert(beetree.Key{K: 45})
-	bt.Insert(beetree.Key{K: 55})
-	bt.Insert(beetree.Key{K: 65})
-	bt.Insert(beetree.Key{K: 75})
-	bt.Insert(beetree.Key{K: 85})
-	bt.Insert(beetree.Key{K: 95})
-	bt.Insert(beetree.Key{K: 105})
+	bt.Insert(beetree.Key[int, int]{K: 10})
+	bt.Insert(beetree.Key[int, int]{K: 20})
+	bt.Insert(beetree.Key[int, int]{K: 30})
+	bt.Insert(beetree.Key[int, int]{K: 40})
+	bt.Insert(beetree.Key[int, int]{K: 50})
+	bt.Insert(beetree.Key[int, int]{K: 60})
+	bt.Insert(beetree.Key[int, int]{K: 5})
+	bt.Insert(beetree.Key[int, int]{K: 15})
+	bt.Insert(beetree.Key[int, int]{K: 25})
+	bt.Insert(beetree.Key[int, int]{K: 35})
+	bt.Insert(beetree.Key[int, int]{K: 45})
+	bt.Insert(beetree.Key[int, int]{K: 55})
+	bt.Insert(beetree.Key[int, int]{K: 65})
+	bt.Insert(beetree.Key[int, int]{K: 75})
+	bt.Insert(beetree.Key[int, int]{K: 85})
+	bt.Insert(beetree.Key[int, int]{K: 95})
+	bt.Insert(beetree.Key[int, int]{K: 105})
 	bt.PrintInLevelOrder()
 
 	// Test left leaf deletion
 	// Redistribution
-	// bt.Delete(beetree.Key{K: 25})
+	// bt.Delete(25)
 	// bt.PrintInLevelOrder()
 
-	// bt.Delete(beetree.Key{K: 35})
+	// bt.Delete(35)
 	// bt.PrintInLevelOrder()
 
-	// bt.Delete(beetree.Key{K: 30})
+	// bt.Delete(30)
 	// bt.PrintInLevelOrder()
 
 	// Test right leaf deletion
 	// Redistribution
-	// bt.Delete(beetree.Key{K: 15})
+	// bt.Delete(15)
 	// bt.PrintInLevelOrder()
 
-	// bt.Delete(beetree.Key{K: 10})
+	// bt.Delete(10)
 	// bt.PrintInLevelOrder()
 
-	// bt.Delete(beetree.Key{K: 5})
+	// bt.Delete(5)
 	// bt.PrintInLevelOrder()
 
 	// Test leaf merge
-	// bt.Delete(beetree.Key{K: 85})
+	// bt.Delete(85)
 	// bt.PrintInLevelOrder()
 
 	// Test intermediate key deletion
-	bt.Delete(beetree.Key{K: 20})
-	bt.Delete(beetree.Key{K: 10})
-	bt.Delete(beetree.Key{K: 15})
-	bt.Delete(beetree.Key{K: 25})
-	bt.Delete(beetree.Key{K: 5})
+	bt.Delete(20)
+	bt.Delete(10)
+	bt.Delete(15)
+	bt.Delete(25)
+	bt.Delete(5)
 
-	bt.Delete(beetree.Key{K: 105})
-	bt.Delete(beetree.Key{K: 95})
-	bt.Delete(beetree.Key{K: 85})
-	bt.Delete(beetree.Key{K: 75})
+	bt.Delete(105)
+	bt.Delete(95)
+	bt.Delete(85)
+	bt.Delete(75)
 
-	bt.Delete(beetree.Key{K: 60})
+	bt.Delete(60)
 
 	bt.PrintInLevelOrder()
 
-	// bt.Insert(beetree.Key{K: 20})
-	// bt.Insert(beetree.Key{K: 22})
-	// bt.Insert(beetree.Key{K: 9})
-	// bt.Insert(beetree.Key{K: 27})
-	// bt.Insert(beetree.Key{K: 18})
-	// bt.Insert(beetree.Key{K: 2})
-	// bt.Insert(beetree.Key{K: 44})
-	// bt.Insert(beetree.Key{K: 5})
-	// bt.Insert(beetree.Key{K: 43})
-	// bt.Insert(beetree.Key{K: 13})
-	// bt.Insert(beetree.Key{K: 34})
-	// bt.Insert(beetree.Key{K: 39})
-	// bt.Insert(beetree.Key{K: 120})
-	// bt.Insert(beetree.Key{K: 220})
-	// bt.Insert(beetree.Key{K: 51})
-	// bt.Insert(beetree.Key{K: 55})
-	// bt.Insert(beetree.Key{K: 68})
-	// bt.Insert(beetree.Key{K: 65})
-	// bt.Insert(beetree.Key{K: 70})
-	// bt.Insert(beetree.Key{K: 21})
-
-	// bt.Insert(beetree.Key{K: 50})
-	// bt.Insert(beetree.Key{K: 20})
+	// bt.Insert(beetree.Key[int, int]{K: 20})
+	// bt.Insert(beetree.Key[int, int]{K: 22})
+	// bt.Insert(beetree.Key[int, int]{K: 9})
+	// bt.Insert(beetree.Key[int, int]{K: 27})
+	// bt.Insert(beetree.Key[int, int]{K: 18})
+	// bt.Insert(beetree.Key[int, int]{K: 2})
+	// bt.Insert(beetree.Key[int, int]{K: 44})
+	// bt.Insert(beetree.Key[int, int]{K: 5})
+	// bt.Insert(beetree.Key[int, int]{K: 43})
+	// bt.Insert(beetree.Key[int, int]{K: 13})
+	// bt.Insert(beetree.Key[int, int]{K: 34})
+	// bt.Insert(beetree.Key[int, int]{K: 39})
+	// bt.Insert(beetree.Key[int, int]{K: 120})
+	// bt.Insert(beetree.Key[int, int]{K: 220})
+	// bt.Insert(beetree.Key[int, int]{K: 51})
+	// bt.Insert(beetree.Key[int, int]{K: 55})
+	// bt.Insert(beetree.Key[int, int]{K: 68})
+	// bt.Insert(beetree.Key[int, int]{K: 65})
+	// bt.Insert(beetree.Key[int, int]{K: 70})
+	// bt.Insert(beetree.Key[int, int]{K: 21})
+
+	// bt.Insert(beetree.Key[int, int]{K: 50})
+	// bt.Insert(beetree.Key[int, int]{K: 20})
 	// // bee.ReplaceOrInsert(beetree.BTreeItem{K: 20, V: "mule"})
-	// bt.Insert(beetree.Key{K: 80})
-	// bt.Insert(beetree.Key{K: 90})
-	// bt.Insert(beetree.Key{K: 70})
-	// bt.Insert(beetree.Key{K: 60})
-	// bt.Insert(beetree.Key{K: 65})
-	// bt.Insert(beetree.Key{K: 69})
+	// bt.Insert(beetree.Key[int, int]{K: 80})
+	// bt.Insert(beetree.Key[int, int]{K: 90})
+	// bt.Insert(beetree.Key[int, int]{K: 70})
+	// bt.Insert(beetree.Key[int, int]{K: 60})
+	// bt.Insert(beetree.Key[int, int]{K: 65})
+	// bt.Insert(beetree.Key[int, int]{K: 69})
 
 	//k := bt.Get(61)
 	//fmt.Println(k)